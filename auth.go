@@ -11,6 +11,33 @@ type Credentials interface {
 	AuthorizeRequest(request *http.Request)
 }
 
+// RefreshableCredentials - учётные данные, срок действия которых может истечь. Если API отклонил запрос с ошибкой 401,
+// ProcessRequest вызывает RefreshRejected и однократно повторяет запрос с обновлёнными учётными данными.
+type RefreshableCredentials interface {
+	Credentials
+
+	// RefreshRejected обновляет учётные данные, с которыми был авторизован отклонённый запрос request.
+	RefreshRejected(request *http.Request) error
+}
+
+// asRefreshableCredentials находит учётные данные, которые можно обновить, среди данных учётных данных и учётных
+// данных, которые они оборачивают (см. Impersonation.Unwrap).
+func asRefreshableCredentials(credentials Credentials) (RefreshableCredentials, bool) {
+	for credentials != nil {
+		if refreshable, ok := credentials.(RefreshableCredentials); ok {
+			return refreshable, true
+		}
+
+		wrapper, ok := credentials.(interface{ Unwrap() Credentials })
+		if !ok {
+			break
+		}
+		credentials = wrapper.Unwrap()
+	}
+
+	return nil, false
+}
+
 type EmptyCredentials struct{}
 
 func (EmptyCredentials) AuthorizeRequest(*http.Request) {}
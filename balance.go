@@ -0,0 +1,173 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+var (
+	// ErrInsufficientBalance возвращается, если после операции баланс пользователя станет отрицательным,
+	// а это не разрешено явно.
+	ErrInsufficientBalance = errors.New("insufficient balance")
+
+	// ErrNonPositiveAmount возвращается, если сумма перевода не больше нуля.
+	ErrNonPositiveAmount = errors.New("amount must be positive")
+
+	// ErrSelfTransfer возвращается при попытке перевести средства самому себе.
+	ErrSelfTransfer = errors.New("cannot transfer balance to the same user")
+)
+
+// AdjustmentReason - код причины корректировки баланса администрацией хостинга.
+type AdjustmentReason string
+
+const (
+	// AdjustmentReasonCompensation - компенсация за простой или другие проблемы на стороне хостинга.
+	AdjustmentReasonCompensation AdjustmentReason = "COMPENSATION"
+
+	// AdjustmentReasonCorrection - исправление ошибочного начисления или списания.
+	AdjustmentReasonCorrection AdjustmentReason = "CORRECTION"
+
+	// AdjustmentReasonBonus - бонус, не связанный с реферальной системой. Например, за участие в акции.
+	AdjustmentReasonBonus AdjustmentReason = "BONUS"
+
+	// AdjustmentReasonChargeback - списание средств, возвращённых пользователю платёжной системой.
+	AdjustmentReasonChargeback AdjustmentReason = "CHARGEBACK"
+
+	// AdjustmentReasonPenalty - списание за нарушение правил хостинга.
+	AdjustmentReasonPenalty AdjustmentReason = "PENALTY"
+)
+
+// TransferForm - параметры перевода средств между пользователями.
+type TransferForm struct {
+	// Идентификатор пользователя, которому переводятся средства.
+	RecipientID int64 `json:"recipientId"`
+
+	// Сумма перевода в рублях. Должна быть больше нуля.
+	Amount Money `json:"amount"`
+
+	// Описание перевода. Отображается в истории платежей обоих пользователей.
+	Description null.String `json:"description"`
+
+	// Если true, перевод будет проведён, даже если после него баланс отправителя станет отрицательным.
+	AllowNegativeBalance bool `json:"allowNegativeBalance"`
+
+	// Ключ идемпотентности (см. IdempotencyKeyHeader). Если не задан, для каждого вызова создаётся новый ключ.
+	IdempotencyKey string `json:"-"`
+}
+
+func (f TransferForm) GetIdempotencyKey() string {
+	return f.IdempotencyKey
+}
+
+// BalanceTransfer - проведённый перевод средств. Состоит из двух платежей с источником PaymentSourceTransfer,
+// которые создаются одновременно: либо оба, либо ни одного.
+type BalanceTransfer struct {
+	// Списание с баланса отправителя.
+	Outgoing Payment `json:"outgoing"`
+
+	// Зачисление на баланс получателя.
+	Incoming Payment `json:"incoming"`
+}
+
+// AdjustmentForm - параметры корректировки баланса пользователя администрацией хостинга.
+type AdjustmentForm struct {
+	// Сумма корректировки в рублях. Положительная сумма зачисляется на баланс, отрицательная - списывается с него.
+	Amount Money `json:"amount"`
+
+	// Код причины корректировки.
+	Reason AdjustmentReason `json:"reason"`
+
+	// Описание корректировки.
+	Description null.String `json:"description"`
+
+	// Если true, списание будет проведено, даже если после него баланс пользователя станет отрицательным.
+	AllowNegativeBalance bool `json:"allowNegativeBalance"`
+
+	// Ключ идемпотентности (см. IdempotencyKeyHeader). Если не задан, для каждого вызова создаётся новый ключ.
+	IdempotencyKey string `json:"-"`
+}
+
+func (f AdjustmentForm) GetIdempotencyKey() string {
+	return f.IdempotencyKey
+}
+
+// TransferBalance переводит средства с баланса данного пользователя на баланс другого пользователя
+// (см. Client.TransferBalance).
+func (u *User) TransferBalance(client *Client, form TransferForm) (*BalanceTransfer, error) {
+	return client.TransferBalance(u.ID, form)
+}
+
+// AdjustBalance корректирует баланс данного пользователя (см. Client.AdjustBalance).
+func (u *User) AdjustBalance(client *Client, form AdjustmentForm) (*Payment, error) {
+	return client.AdjustBalance(u.ID, form)
+}
+
+// TransferBalance переводит средства с баланса пользователя senderID на баланс пользователя form.RecipientID.
+// Перевод проводится одной операцией: списание и зачисление либо происходят вместе, либо не происходят вовсе.
+// Если form.AllowNegativeBalance = false, перед переводом проверяется баланс отправителя, и при нехватке средств
+// возвращается ErrInsufficientBalance. Ту же проверку производит и API, поэтому перевод не будет проведён, даже
+// если баланс изменился между проверкой и переводом.
+func (c *Client) TransferBalance(senderID int64, form TransferForm) (*BalanceTransfer, error) {
+	if form.Amount.IsNegative() || form.Amount.IsZero() {
+		return nil, ErrNonPositiveAmount
+	}
+
+	if senderID == form.RecipientID {
+		return nil, ErrSelfTransfer
+	}
+
+	if !form.AllowNegativeBalance {
+		sender, err := c.GetUser(senderID)
+		if err != nil {
+			return nil, fmt.Errorf("getting sender: %w", err)
+		}
+
+		err = checkSufficientBalance(sender, form.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return InvokeEndpoint[BalanceTransfer](c, http.MethodPost, fmt.Sprintf("/users/%d/transfers", senderID), form)
+}
+
+// AdjustBalance корректирует баланс пользователя с заданным идентификатором и возвращает созданный платёж
+// с источником PaymentSourceAdjustment. Если сумма корректировки отрицательна, а form.AllowNegativeBalance = false,
+// перед списанием проверяется баланс пользователя, и при нехватке средств возвращается ErrInsufficientBalance.
+func (c *Client) AdjustBalance(userID int64, form AdjustmentForm) (*Payment, error) {
+	if form.Reason == "" {
+		return nil, errors.New("adjustment reason is not specified")
+	}
+
+	if form.Amount.IsNegative() && !form.AllowNegativeBalance {
+		user, err := c.GetUser(userID)
+		if err != nil {
+			return nil, fmt.Errorf("getting user: %w", err)
+		}
+
+		err = checkSufficientBalance(user, form.Amount.Neg())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return InvokeEndpoint[Payment](c, http.MethodPost, fmt.Sprintf("/users/%d/adjustments", userID), form)
+}
+
+// checkSufficientBalance возвращает ErrInsufficientBalance, если после списания debit баланс пользователя станет
+// отрицательным.
+func checkSufficientBalance(user *User, debit Money) error {
+	remaining, err := user.Balance.Sum.Sub(debit)
+	if err != nil {
+		return err
+	}
+
+	if remaining.IsNegative() {
+		return fmt.Errorf("%w: balance %s, required %s", ErrInsufficientBalance, user.Balance.Sum, debit)
+	}
+
+	return nil
+}
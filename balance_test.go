@@ -0,0 +1,143 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// newBalanceServer создаёт тестовый API, в котором у пользователя 1 на балансе balance рублей. Сервер запоминает
+// адреса изменяющих запросов и их тела.
+func newBalanceServer(balance string, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		switch {
+		case request.Method == http.MethodGet && request.URL.Path == "/users/1":
+			_, _ = fmt.Fprintf(writer, `{"id": 1, "name": "Steve", "balance": {"sum": %s, "currency": "RUB"}}`, balance)
+		case request.Method == http.MethodPost && request.URL.Path == "/users/1/transfers":
+			body, _ := io.ReadAll(request.Body)
+			*requests = append(*requests, request.URL.Path+" "+string(body))
+			_, _ = writer.Write([]byte(`{"outgoing": {"id": "o1", "userId": 1, "amount": {"sum": -30, "currency": "RUB"}, "completed": true},
+				"incoming": {"id": "i1", "userId": 2, "amount": {"sum": 30, "currency": "RUB"}, "completed": true}}`))
+		case request.Method == http.MethodPost && request.URL.Path == "/users/1/adjustments":
+			body, _ := io.ReadAll(request.Body)
+			*requests = append(*requests, request.URL.Path+" "+string(body))
+			_, _ = writer.Write([]byte(`{"id": "a1", "userId": 1, "amount": {"sum": -30, "currency": "RUB"}, "completed": true}`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = writer.Write([]byte(`{"error": "Not Found", "message": "not found", "status": 404}`))
+		}
+	}))
+}
+
+func TestClient_TransferBalanceValidation(t *testing.T) {
+	var requests []string
+	server := newBalanceServer("100", &requests)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	forms := map[string]struct {
+		form     TransferForm
+		expected error
+	}{
+		"zero amount":     {TransferForm{RecipientID: 2}, ErrNonPositiveAmount},
+		"negative amount": {TransferForm{RecipientID: 2, Amount: rubles(-10)}, ErrNonPositiveAmount},
+		"self transfer":   {TransferForm{RecipientID: 1, Amount: rubles(10)}, ErrSelfTransfer},
+		"exceeds balance": {TransferForm{RecipientID: 2, Amount: rubles(100.01)}, ErrInsufficientBalance},
+	}
+
+	for name, test := range forms {
+		_, err := client.TransferBalance(1, test.form)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, err)
+		}
+	}
+
+	assert.Equal(t, len(requests), 0)
+}
+
+func TestClient_TransferBalance(t *testing.T) {
+	var requests []string
+	server := newBalanceServer("100", &requests)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+
+	// Перевод всего баланса оставляет его нулевым, что допустимо.
+	transfer, err := client.TransferBalance(1, TransferForm{RecipientID: 2, Amount: rubles(100)})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, transfer.Incoming.UserID, int64(2))
+
+	// С разрешением отрицательного баланса баланс отправителя не проверяется.
+	_, err = client.TransferBalance(1, TransferForm{RecipientID: 2, Amount: rubles(150), AllowNegativeBalance: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, requests, []string{
+		`/users/1/transfers {"recipientId":2,"amount":100,"description":null,"allowNegativeBalance":false}` + "\n",
+		`/users/1/transfers {"recipientId":2,"amount":150,"description":null,"allowNegativeBalance":true}` + "\n",
+	})
+}
+
+func TestClient_TransferBalanceUnknownSender(t *testing.T) {
+	var requests []string
+	server := newBalanceServer("100", &requests)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	_, err := client.TransferBalance(3, TransferForm{RecipientID: 2, Amount: rubles(10)})
+
+	var errorResponse *ErrorResponse
+	assert.Equal(t, errors.As(err, &errorResponse), true)
+	assert.Equal(t, errorResponse.Status, http.StatusNotFound)
+}
+
+func TestClient_AdjustBalance(t *testing.T) {
+	var requests []string
+	server := newBalanceServer("20.5", &requests)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+
+	_, err := client.AdjustBalance(1, AdjustmentForm{Amount: rubles(10)})
+	assert.NotEqual(t, err, nil)
+
+	tests := []struct {
+		name     string
+		form     AdjustmentForm
+		expected error
+	}{
+		{"exceeds balance", AdjustmentForm{Amount: rubles(-20.51), Reason: AdjustmentReasonPenalty}, ErrInsufficientBalance},
+		{"whole balance", AdjustmentForm{Amount: rubles(-20.5), Reason: AdjustmentReasonChargeback}, nil},
+		{"negative allowed", AdjustmentForm{Amount: rubles(-30), Reason: AdjustmentReasonPenalty, AllowNegativeBalance: true}, nil},
+		{"credit", AdjustmentForm{Amount: rubles(500), Reason: AdjustmentReasonCompensation}, nil},
+	}
+
+	for _, test := range tests {
+		_, err = client.AdjustBalance(1, test.form)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+	}
+
+	assert.Equal(t, len(requests), 3)
+}
+
+func TestCheckSufficientBalance(t *testing.T) {
+	user := &User{ID: 1, Balance: PaymentAmount{Sum: rubles(10), Currency: CurrencyRUB}}
+
+	assert.Equal(t, checkSufficientBalance(user, rubles(9.99)), nil)
+	assert.Equal(t, checkSufficientBalance(user, rubles(10)), nil)
+	assert.Equal(t, errors.Is(checkSufficientBalance(user, rubles(10.01)), ErrInsufficientBalance), true)
+	assert.Equal(t, errors.Is(checkSufficientBalance(user, NewMoney(100, "USD")), ErrCurrencyMismatch), true)
+}
@@ -0,0 +1,120 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrNoTariff возвращается при попытке получить тариф сервера, на котором используется произвольная конфигурация.
+var ErrNoTariff = errors.New("server has no tariff")
+
+// ResourcePrices - стоимость единицы каждого из ресурсов в рублях. Период, за который указана стоимость, зависит от
+// того, в какой части набора цен находится структура (см. PriceSet).
+type ResourcePrices struct {
+	// Стоимость одного ядра ЦПУ.
+	CPU float64 `json:"cpu"`
+
+	// Стоимость одного ГБ оперативной памяти.
+	Memory float64 `json:"memory"`
+
+	// Стоимость одного ГБ дискового пространства.
+	Disk float64 `json:"disk"`
+
+	// Стоимость одной базы данных.
+	Database float64 `json:"database"`
+
+	// Стоимость одного слота для резервной копии.
+	Backup float64 `json:"backup"`
+}
+
+// PriceSet - набор цен, по которому рассчитывается стоимость серверов на нодах, использующих этот набор
+// (см. Node.PriceSetName). Итоговая стоимость дополнительно умножается на множитель ноды (см. Node.Multiplier).
+type PriceSet struct {
+	// Название набора цен. Является его идентификатором.
+	Name string `json:"name"`
+
+	// Стоимость ресурсов за один день. Используется в тарифном режиме TariffModeDailyResources.
+	Daily ResourcePrices `json:"daily"`
+
+	// Стоимость ресурсов за один месяц. Используется в тарифном режиме TariffModeMonthlyTariff.
+	Monthly ResourcePrices `json:"monthly"`
+}
+
+// Tariff - готовый тариф, который пользователь может выбрать при покупке сервера вместо произвольной конфигурации.
+type Tariff struct {
+	// Идентификатор тарифа. Совпадает со значением ServerBillingConfig.TariffID у серверов, использующих тариф.
+	ID string `json:"id"`
+
+	// Название линейки тарифов, к которой относится тариф.
+	SetName string `json:"setName"`
+
+	// Название тарифа, отображаемое пользователям.
+	Name string `json:"name"`
+
+	// Ресурсы, выделяемые серверу на данном тарифе.
+	Resources Resources `json:"resources"`
+
+	// Ограничения дополнительных возможностей сервера на данном тарифе.
+	FeatureLimits FeatureLimits `json:"featureLimits"`
+
+	// Стоимость тарифа в рублях за месяц без учёта множителя ноды.
+	Price float64 `json:"price"`
+
+	// Скрыт ли тариф от пользователей? Если true, то тариф не будет отображён при покупке сервера.
+	Hidden bool `json:"hidden"`
+}
+
+// TariffSet - линейка тарифов, доступных для покупки на нодах, использующих эту линейку (см. Node.TariffSetName).
+type TariffSet struct {
+	// Название линейки тарифов. Является её идентификатором.
+	Name string `json:"name"`
+
+	// Тарифы, входящие в линейку.
+	Tariffs []Tariff `json:"tariffs"`
+}
+
+// GetPriceSet получает набор цен, используемый для серверов на данной ноде.
+func (n *Node) GetPriceSet(client *Client) (*PriceSet, error) {
+	return client.GetPriceSet(n.PriceSetName)
+}
+
+// GetTariffSet получает линейку тарифов, используемую для серверов на данной ноде.
+func (n *Node) GetTariffSet(client *Client) (*TariffSet, error) {
+	return client.GetTariffSet(n.TariffSetName)
+}
+
+// GetTariff получает тариф, используемый на сервере в данный момент. Вернёт ErrNoTariff, если на сервере
+// используется произвольная конфигурация.
+func (s *Server) GetTariff(client *Client) (*Tariff, error) {
+	if !s.Billing.TariffID.Valid {
+		return nil, ErrNoTariff
+	}
+
+	return client.GetTariff(s.Billing.TariffID.String)
+}
+
+// GetPriceSets получает список всех наборов цен.
+func (c *Client) GetPriceSets() (*[]PriceSet, error) {
+	return InvokeEndpoint[[]PriceSet](c, http.MethodGet, "/price-sets", nil)
+}
+
+// GetPriceSet получает набор цен с заданным названием.
+func (c *Client) GetPriceSet(name string) (*PriceSet, error) {
+	return InvokeEndpoint[PriceSet](c, http.MethodGet, fmt.Sprintf("/price-sets/%s", name), nil)
+}
+
+// GetTariffSets получает список всех линеек тарифов.
+func (c *Client) GetTariffSets() (*[]TariffSet, error) {
+	return InvokeEndpoint[[]TariffSet](c, http.MethodGet, "/tariff-sets", nil)
+}
+
+// GetTariffSet получает линейку тарифов с заданным названием.
+func (c *Client) GetTariffSet(name string) (*TariffSet, error) {
+	return InvokeEndpoint[TariffSet](c, http.MethodGet, fmt.Sprintf("/tariff-sets/%s", name), nil)
+}
+
+// GetTariff получает тариф с заданным идентификатором.
+func (c *Client) GetTariff(id string) (*Tariff, error) {
+	return InvokeEndpoint[Tariff](c, http.MethodGet, fmt.Sprintf("/tariffs/%s", id), nil)
+}
@@ -0,0 +1,138 @@
+package superhub
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"gopkg.in/guregu/null.v4"
+)
+
+const testTariffSetJSON = `{
+	"name": "standard",
+	"tariffs": [
+		{
+			"id": "standard-2",
+			"setName": "standard",
+			"name": "Standard 2",
+			"resources": {"cpu": 2, "memory": 4, "disk": 30},
+			"featureLimits": {"databases": 2, "backups": 3},
+			"price": 450.5,
+			"hidden": false
+		},
+		{
+			"id": "legacy-1",
+			"setName": "standard",
+			"name": "Legacy 1",
+			"resources": {"cpu": 1, "memory": 1, "disk": 10},
+			"featureLimits": {"databases": 0, "backups": 1},
+			"price": 99,
+			"hidden": true
+		}
+	]
+}`
+
+const testPriceSetJSON = `{
+	"name": "default",
+	"daily": {"cpu": 5, "memory": 2.5, "disk": 0.1, "database": 1, "backup": 0.5},
+	"monthly": {"cpu": 140, "memory": 70, "disk": 3, "database": 25, "backup": 12}
+}`
+
+// newCatalogueServer создаёт тестовый API, который возвращает каталог по адресам из routes, а на остальные
+// запросы отвечает ошибкой 404.
+func newCatalogueServer(routes map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		body, ok := routes[request.URL.Path]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = writer.Write([]byte(`{"error": "Not Found", "message": "not found", "path": "` + request.URL.Path + `", "status": 404}`))
+			return
+		}
+
+		_, _ = writer.Write([]byte(body))
+	}))
+}
+
+func TestNode_GetTariffSet(t *testing.T) {
+	server := newCatalogueServer(map[string]string{"/tariff-sets/standard": testTariffSetJSON})
+	defer server.Close()
+
+	node := &Node{ID: 1, TariffSetName: "standard"}
+	tariffSet, err := node.GetTariffSet(&Client{BaseURL: server.URL})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, tariffSet.Name, "standard")
+	assert.Equal(t, len(tariffSet.Tariffs), 2)
+	assert.Equal(t, tariffSet.Tariffs[0], Tariff{
+		ID:            "standard-2",
+		SetName:       "standard",
+		Name:          "Standard 2",
+		Resources:     Resources{CPU: 2, Memory: 4, Disk: 30},
+		FeatureLimits: FeatureLimits{Databases: 2, Backups: 3},
+		Price:         450.5,
+	})
+	assert.Equal(t, tariffSet.Tariffs[1].Hidden, true)
+}
+
+func TestNode_GetPriceSet(t *testing.T) {
+	server := newCatalogueServer(map[string]string{"/price-sets/default": testPriceSetJSON})
+	defer server.Close()
+
+	node := &Node{ID: 1, PriceSetName: "default"}
+	priceSet, err := node.GetPriceSet(&Client{BaseURL: server.URL})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, *priceSet, PriceSet{
+		Name:    "default",
+		Daily:   ResourcePrices{CPU: 5, Memory: 2.5, Disk: 0.1, Database: 1, Backup: 0.5},
+		Monthly: ResourcePrices{CPU: 140, Memory: 70, Disk: 3, Database: 25, Backup: 12},
+	})
+}
+
+func TestNode_GetCatalogueNotFound(t *testing.T) {
+	server := newCatalogueServer(map[string]string{})
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	node := &Node{ID: 1, PriceSetName: "missing", TariffSetName: "missing"}
+
+	for _, get := range []func() error{
+		func() error { _, err := node.GetPriceSet(client); return err },
+		func() error { _, err := node.GetTariffSet(client); return err },
+		func() error { _, err := client.GetTariff("missing"); return err },
+	} {
+		var errorResponse *ErrorResponse
+		err := get()
+		assert.Equal(t, errors.As(err, &errorResponse), true)
+		assert.Equal(t, errorResponse.Status, http.StatusNotFound)
+	}
+}
+
+func TestServer_GetTariff(t *testing.T) {
+	server := newCatalogueServer(map[string]string{
+		"/tariffs/standard-2": `{"id": "standard-2", "setName": "standard", "name": "Standard 2", "price": 450.5}`,
+	})
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+
+	tariff, err := (&Server{Billing: ServerBillingConfig{TariffID: null.StringFrom("standard-2")}}).GetTariff(client)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, tariff.Name, "Standard 2")
+
+	_, err = (&Server{}).GetTariff(client)
+	assert.Equal(t, errors.Is(err, ErrNoTariff), true)
+}
@@ -0,0 +1,140 @@
+package superhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultPaymentPollInterval - интервал между проверками состояния платежа в WaitForPaymentCompleted,
+// если он не задан явно.
+const DefaultPaymentPollInterval = 5 * time.Second
+
+// ErrUnexpectedPaymentMode возвращается, если клиент работает в тестовом режиме (см. Client.TestMode), а система
+// создала платёж, который будет обработан полностью.
+var ErrUnexpectedPaymentMode = errors.New("payment was not created in test mode")
+
+// ErrPaymentAbandoned возвращается, если ожидаемый платёж уже никогда не будет завершён (см. Payment.IsAbandoned).
+// Подробности содержит PaymentAbandonedError.
+var ErrPaymentAbandoned = errors.New("payment will not be completed")
+
+// PaymentAbandonedError - ошибка ожидания платежа, оплата которого была отклонена, отменена или не произведена
+// в срок. Проверяется с помощью errors.Is(err, ErrPaymentAbandoned).
+type PaymentAbandonedError struct {
+	// Платёж в последнем полученном состоянии.
+	Payment *Payment
+}
+
+func (e *PaymentAbandonedError) Error() string {
+	return fmt.Sprintf("payment %s will not be completed: status %s", e.Payment.ID, e.Payment.Status)
+}
+
+func (e *PaymentAbandonedError) Is(target error) bool {
+	return target == ErrPaymentAbandoned
+}
+
+// TopUpForm - параметры пополнения баланса текущим пользователем.
+type TopUpForm struct {
+	// Сумма пополнения в рублях.
+	Amount Money `json:"amount"`
+
+	// Платёжная система, через которую пользователь будет производить оплату. Если не задана, используется
+	// платёжная система по умолчанию.
+	Provider string `json:"provider,omitempty"`
+
+	// Адрес страницы, на которую платёжная система вернёт пользователя после оплаты.
+	ReturnURL string `json:"returnUrl,omitempty"`
+
+	// Режим проведения платежа. Если клиент работает в тестовом режиме (см. Client.TestMode), всегда
+	// принимает значение PaymentModeTest.
+	Mode PaymentMode `json:"mode,omitempty"`
+
+	// Ключ идемпотентности (см. IdempotencyKeyHeader). Если не задан, для каждого вызова создаётся новый ключ.
+	IdempotencyKey string `json:"-"`
+}
+
+func (f TopUpForm) GetIdempotencyKey() string {
+	return f.IdempotencyKey
+}
+
+// TopUpCheckout - созданное пополнение баланса, ожидающее оплаты.
+type TopUpCheckout struct {
+	// Идентификатор созданного платежа.
+	PaymentID string `json:"paymentId"`
+
+	// Адрес страницы оплаты в платёжной системе, на которую необходимо перенаправить пользователя.
+	RedirectURL string `json:"redirectUrl"`
+
+	// Созданный платёж. До оплаты имеет значение Completed = false.
+	Payment Payment `json:"payment"`
+}
+
+// WaitForCompleted ожидает завершения платежа, созданного при пополнении баланса (см. WaitForPaymentCompleted).
+func (t *TopUpCheckout) WaitForCompleted(ctx context.Context, client *Client, interval time.Duration) (*Payment, error) {
+	return client.WaitForPaymentCompleted(ctx, t.PaymentID, interval)
+}
+
+// CreateTopUp создаёт пополнение баланса текущего пользователя и возвращает адрес страницы оплаты. Баланс будет
+// пополнен только после того, как пользователь произведёт оплату (см. WaitForPaymentCompleted).
+func (c *Client) CreateTopUp(form TopUpForm) (*TopUpCheckout, error) {
+	if c.TestMode {
+		form.Mode = PaymentModeTest
+	}
+
+	checkout, err := InvokeEndpoint[TopUpCheckout](c, http.MethodPost, fmt.Sprintf("/users/%s/top-ups", c.getCurrentUserReference()), form)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.TestMode && checkout.Payment.Mode != PaymentModeTest {
+		return nil, fmt.Errorf("%w: payment %s has mode %q", ErrUnexpectedPaymentMode, checkout.PaymentID, checkout.Payment.Mode)
+	}
+
+	return checkout, nil
+}
+
+// WaitForPaymentCompleted периодически получает платёж с заданным идентификатором, пока он не будет завершён,
+// и возвращает завершённый платёж. Ожидание прерывается при отмене контекста ctx, в том числе во время запроса,
+// при ошибке запроса или с ошибкой PaymentAbandonedError, если платёж уже никогда не будет завершён. Если интервал
+// interval не задан, используется DefaultPaymentPollInterval.
+func (c *Client) WaitForPaymentCompleted(ctx context.Context, paymentID string, interval time.Duration) (*Payment, error) {
+	if interval <= 0 {
+		interval = DefaultPaymentPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		payment, err := c.getPaymentContext(ctx, paymentID)
+		if err != nil {
+			return nil, fmt.Errorf("getting payment: %w", err)
+		}
+
+		if payment.Completed {
+			return payment, nil
+		}
+
+		if payment.IsAbandoned() {
+			return nil, &PaymentAbandonedError{Payment: payment}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// getPaymentContext получает платёж так же, как GetPayment, но запрос прерывается при отмене контекста ctx.
+func (c *Client) getPaymentContext(ctx context.Context, paymentID string) (*Payment, error) {
+	request, err := newEndpointRequest(c, http.MethodGet, fmt.Sprintf("/payments/%s", paymentID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ProcessRequest[Payment](c, request.WithContext(ctx))
+}
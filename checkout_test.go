@@ -0,0 +1,170 @@
+package superhub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// newTopUpServer создаёт тестовый API, который создаёт пополнение баланса в режиме mode, если он задан, иначе
+// в режиме из запроса, и запоминает режим из запроса.
+func newTopUpServer(mode PaymentMode, requestedMode *PaymentMode) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+
+		var form TopUpForm
+		_ = json.Unmarshal(body, &form)
+		*requestedMode = form.Mode
+		createdMode := mode
+		if createdMode == "" {
+			createdMode = form.Mode
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(writer, `{"paymentId": "p1", "redirectUrl": "https://pay.example.com/p1",
+			"payment": {"id": "p1", "userId": 1, "amount": {"sum": 100, "currency": "RUB"}, "mode": %q, "completed": false}}`, createdMode)
+	}))
+}
+
+func TestClient_CreateTopUpTestMode(t *testing.T) {
+	var requestedMode PaymentMode
+	server := newTopUpServer("", &requestedMode)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, TestMode: true}
+	checkout, err := client.CreateTopUp(TopUpForm{Amount: rubles(100), Mode: PaymentModeProduction})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, requestedMode, PaymentMode(PaymentModeTest))
+	assert.Equal(t, checkout.Payment.Mode, PaymentMode(PaymentModeTest))
+	assert.Equal(t, checkout.RedirectURL, "https://pay.example.com/p1")
+}
+
+func TestClient_CreateTopUpUnexpectedMode(t *testing.T) {
+	var requestedMode PaymentMode
+	server := newTopUpServer(PaymentModeProduction, &requestedMode)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, TestMode: true}
+	_, err := client.CreateTopUp(TopUpForm{Amount: rubles(100)})
+	assert.Equal(t, errors.Is(err, ErrUnexpectedPaymentMode), true)
+
+	client.TestMode = false
+	checkout, err := client.CreateTopUp(TopUpForm{Amount: rubles(100)})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, requestedMode, PaymentMode(""))
+	assert.Equal(t, checkout.Payment.Mode, PaymentMode(PaymentModeProduction))
+}
+
+// newPaymentPollingServer создаёт тестовый API, который возвращает ожидающий оплаты платёж, а начиная
+// с запроса номер after - платёж со стадией status.
+func newPaymentPollingServer(after int32, status PaymentStatus, requests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		current, completed := PaymentStatusPending, false
+		if requests.Add(1) >= after {
+			current, completed = status, status == PaymentStatusCompleted
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(writer, `{"id": "p1", "userId": 1, "amount": {"sum": 100, "currency": "RUB"}, "status": %q, "completed": %t}`,
+			current, completed)
+	}))
+}
+
+func TestClient_WaitForPaymentCompleted(t *testing.T) {
+	var requests atomic.Int32
+	server := newPaymentPollingServer(3, PaymentStatusCompleted, &requests)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	payment, err := client.WaitForPaymentCompleted(context.Background(), "p1", time.Millisecond)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, payment.Completed, true)
+	assert.Equal(t, requests.Load(), int32(3))
+}
+
+func TestClient_WaitForPaymentAbandoned(t *testing.T) {
+	for _, status := range []PaymentStatus{PaymentStatusFailed, PaymentStatusCancelled, PaymentStatusExpired} {
+		var requests atomic.Int32
+		server := newPaymentPollingServer(2, status, &requests)
+
+		client := &Client{BaseURL: server.URL}
+		_, err := client.WaitForPaymentCompleted(context.Background(), "p1", time.Millisecond)
+		server.Close()
+
+		var abandonedError *PaymentAbandonedError
+		assert.Equal(t, errors.Is(err, ErrPaymentAbandoned), true)
+		assert.Equal(t, errors.As(err, &abandonedError), true)
+		assert.Equal(t, abandonedError.Payment.Status, status)
+		assert.Equal(t, requests.Load(), int32(2))
+	}
+}
+
+func TestClient_WaitForPaymentCompletedContext(t *testing.T) {
+	var requests atomic.Int32
+	server := newPaymentPollingServer(0, PaymentStatusPending, &requests)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	client := &Client{BaseURL: server.URL}
+	_, err := client.WaitForPaymentCompleted(ctx, "p1", time.Millisecond)
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+}
+
+func TestClient_WaitForPaymentCompletedCancelsRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Ответ не отправляется, пока клиент не прервёт запрос.
+		<-request.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	client := &Client{BaseURL: server.URL}
+	started := time.Now()
+	_, err := client.WaitForPaymentCompleted(ctx, "p1", time.Millisecond)
+
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert.Equal(t, time.Since(started) < time.Second, true)
+}
+
+func TestClient_WaitForPaymentCompletedRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusNotFound)
+		_, _ = writer.Write([]byte(`{"error": "Not Found", "message": "payment not found", "status": 404}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	_, err := client.WaitForPaymentCompleted(context.Background(), "p1", time.Millisecond)
+
+	var requestError *RequestError
+	var errorResponse *ErrorResponse
+	assert.Equal(t, errors.As(err, &requestError), true)
+	assert.Equal(t, errors.As(err, &errorResponse), true)
+	assert.Equal(t, errorResponse.Status, http.StatusNotFound)
+}
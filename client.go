@@ -2,17 +2,49 @@ package superhub
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
+	"time"
 )
 
 const DefaultBaseURL = "https://api.superhub.host/v2"
 
+const DefaultRetryDelay = 500 * time.Millisecond
+
 type Client struct {
 	Credentials Credentials
 	BaseURL     string
 	HttpClient  *http.Client
+
+	// Количество повторных попыток отправки запроса при сетевой ошибке или ошибке сервера (5xx). Повторно
+	// отправляются только запросы, повторение которых безопасно: GET, HEAD, OPTIONS и запросы с ключом
+	// идемпотентности (см. IdempotencyKeyHeader). По умолчанию повторные попытки не производятся.
+	MaxRetries int
+
+	// Задержка перед первой повторной попыткой. Каждая следующая задержка вдвое больше предыдущей.
+	// Если не задана, используется DefaultRetryDelay.
+	RetryDelay time.Duration
+
+	// Если true, платежи, которые создаёт клиент от имени пользователя (например, пополнение баланса через
+	// CreateTopUp), проводятся в тестовом режиме (см. PaymentModeTest) и не приводят к реальному списанию средств.
+	// Используется в тестовых окружениях.
+	TestMode bool
+
+	// Если задан, запросы отправляются не чаще, чем позволяет ограничитель, а запросы, отклонённые API с ошибкой 429,
+	// повторяются после окончания ограничения. По умолчанию частота запросов не ограничивается.
+	RateLimiter *RateLimiter
+
+	// Если задан, каждый запрос записывается в журнал вместе с ответом и временем выполнения: успешные - на уровне
+	// Debug, завершившиеся ошибкой - на уровне Warn. Учётные данные, адреса электронной почты и поля, похожие
+	// на токены, в журнале скрываются. По умолчанию запросы не записываются.
+	Logger *slog.Logger
+
+	// Максимальный размер тела запроса и ответа в журнале в байтах. Если не задан, используется DefaultLogBodyLimit,
+	// отрицательное значение отключает запись тел.
+	LogBodyLimit int
 }
 
 func (c *Client) GetCredentials() Credentials {
@@ -23,6 +55,13 @@ func (c *Client) GetCredentials() Credentials {
 	return c.Credentials
 }
 
+// withCredentials возвращает копию клиента, которая авторизует запросы учётными данными credentials.
+func (c *Client) withCredentials(credentials Credentials) *Client {
+	copied := *c
+	copied.Credentials = credentials
+	return &copied
+}
+
 func (c *Client) GetBaseURL() string {
 	baseURL := DefaultBaseURL
 
@@ -39,10 +78,22 @@ func (c *Client) GetEndpointURL(endpoint string) (string, error) {
 		return "", fmt.Errorf("parsing url: %s", err)
 	}
 
+	// Параметры запроса добавляются к адресу отдельно, иначе "?" будет экранирован как часть пути.
+	endpoint, query, _ := strings.Cut(endpoint, "?")
+
 	parsedURL.Path = path.Join(parsedURL.Path, endpoint)
+	parsedURL.RawQuery = query
 	return parsedURL.String(), nil
 }
 
+func (c *Client) GetRetryDelay() time.Duration {
+	if c.RetryDelay <= 0 {
+		return DefaultRetryDelay
+	}
+
+	return c.RetryDelay
+}
+
 func (c *Client) GetHttpClient() *http.Client {
 	if c.HttpClient == nil {
 		return &http.Client{}
@@ -54,3 +54,14 @@ func TestClient_GetEndpointURL(t *testing.T) {
 		}
 	}
 }
+
+func TestClient_GetEndpointURLWithQuery(t *testing.T) {
+	client := &Client{BaseURL: "https://api.superhub.host/v3/"}
+	endpointURL, err := client.GetEndpointURL("/users/1/servers?external=true&page=2")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, endpointURL, "https://api.superhub.host/v3/users/1/servers?external=true&page=2")
+}
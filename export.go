@@ -0,0 +1,420 @@
+package superhub
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PaymentFilter - условия отбора платежей при выгрузке.
+type PaymentFilter struct {
+	// Начало периода (включительно). Если не задано, период не ограничен снизу.
+	From time.Time
+
+	// Конец периода (не включительно). Если не задан, период не ограничен сверху.
+	To time.Time
+
+	// Типы источников платежей, которые необходимо выгрузить. Если не заданы, выгружаются платежи с любым источником.
+	Sources []PaymentSourceType
+}
+
+// Match возвращает true, если платёж удовлетворяет условиям отбора.
+func (f *PaymentFilter) Match(payment *Payment) bool {
+	if !f.From.IsZero() && payment.CreatedAt.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && !payment.CreatedAt.Before(f.To) {
+		return false
+	}
+
+	if len(f.Sources) == 0 {
+		return true
+	}
+
+	for _, source := range f.Sources {
+		if payment.Source.Type == source {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PaymentTotals - итоги по выгруженным платежам.
+type PaymentTotals struct {
+	// Количество выгруженных платежей.
+	Count int `json:"count"`
+
+	// Сумма платежей по каждой из валют.
+	ByCurrency map[string]Money `json:"byCurrency"`
+
+	// Сумма платежей по каждому из типов источника и каждой из валют.
+	BySource map[PaymentSourceType]map[string]Money `json:"bySource"`
+}
+
+func newPaymentTotals() *PaymentTotals {
+	return &PaymentTotals{
+		ByCurrency: make(map[string]Money),
+		BySource:   make(map[PaymentSourceType]map[string]Money),
+	}
+}
+
+func (t *PaymentTotals) add(payment *Payment) {
+	amount := payment.Amount.Sum
+	currency := amount.Currency
+
+	// Суммы сгруппированы по валюте, поэтому сложение не может завершиться ошибкой.
+	t.ByCurrency[currency], _ = t.ByCurrency[currency].Add(amount)
+
+	if t.BySource[payment.Source.Type] == nil {
+		t.BySource[payment.Source.Type] = make(map[string]Money)
+	}
+	t.BySource[payment.Source.Type][currency], _ = t.BySource[payment.Source.Type][currency].Add(amount)
+
+	t.Count++
+}
+
+// PaymentExporter выгружает платежи в форматы, пригодные для бухгалтерского учёта. Платежи, не удовлетворяющие
+// условиям отбора Filter, пропускаются, остальные записываются в порядке создания по одному, не накапливая
+// результат в памяти.
+type PaymentExporter struct {
+	// Клиент, с помощью которого получаются адреса электронной почты пользователей (см. GetUser).
+	// Если не задан, адреса не выгружаются.
+	Client *Client
+
+	// Условия отбора платежей.
+	Filter PaymentFilter
+
+	emails map[int64]string
+}
+
+// NewPaymentExporter создаёт выгрузку платежей, удовлетворяющих условиям filter.
+func NewPaymentExporter(client *Client, filter PaymentFilter) *PaymentExporter {
+	return &PaymentExporter{Client: client, Filter: filter}
+}
+
+var paymentCSVHeader = []string{
+	"id", "created_at", "user_id", "user_email", "amount", "currency",
+	"source_type", "source_id", "mode", "completed", "description",
+}
+
+// ExportCSV записывает платежи в формате CSV с заголовком. Суммы записываются с точкой в качестве разделителя.
+func (e *PaymentExporter) ExportCSV(writer io.Writer, payments []Payment) (*PaymentTotals, error) {
+	csvWriter := csv.NewWriter(writer)
+
+	err := csvWriter.Write(paymentCSVHeader)
+	if err != nil {
+		return nil, fmt.Errorf("writing header: %s", err)
+	}
+
+	totals, err := e.export(payments, func(payment *Payment, email string) error {
+		return csvWriter.Write([]string{
+			payment.ID,
+			payment.CreatedAt.Format(time.RFC3339),
+			strconv.FormatInt(payment.UserID, 10),
+			email,
+			payment.Amount.Sum.Decimal(),
+			payment.Amount.Currency,
+			string(payment.Source.Type),
+			payment.Source.ID.String,
+			string(payment.Mode),
+			strconv.FormatBool(payment.Completed),
+			payment.Description.String,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	csvWriter.Flush()
+	return totals, csvWriter.Error()
+}
+
+// exportedPayment - платёж вместе с адресом электронной почты пользователя.
+type exportedPayment struct {
+	Payment
+	UserEmail string `json:"userEmail,omitempty"`
+}
+
+// ExportJSONLines записывает платежи в формате JSON Lines: по одному JSON объекту на строку.
+func (e *PaymentExporter) ExportJSONLines(writer io.Writer, payments []Payment) (*PaymentTotals, error) {
+	encoder := json.NewEncoder(writer)
+
+	return e.export(payments, func(payment *Payment, email string) error {
+		return encoder.Encode(exportedPayment{Payment: *payment, UserEmail: email})
+	})
+}
+
+// OneCExchangeOptions - реквизиты, необходимые для выгрузки в формате обмена с 1С (см. Export1C).
+type OneCExchangeOptions struct {
+	// Программа-отправитель. Например, "SuperHub".
+	Sender string
+
+	// Программа-получатель. Например, "Бухгалтерия предприятия".
+	Recipient string
+
+	// Расчётный счёт организации.
+	Account string
+
+	// Наименование организации.
+	CompanyName string
+
+	// ИНН организации.
+	CompanyINN string
+}
+
+// Export1C записывает платежи в формате обмена 1С с клиентом банка (1CClientBankExchange) в кодировке Windows-1251.
+// Пополнения баланса записываются как поступления на расчётный счёт организации, списания - как платежи
+// организации пользователю. Суммы в валюте, отличной от рубля, пропускаются, так как формат их не поддерживает.
+// Переводы строк и другие управляющие символы в значениях (например, в описании платежа) заменяются пробелами,
+// так как каждая строка файла является отдельным реквизитом.
+func (e *PaymentExporter) Export1C(writer io.Writer, payments []Payment, options OneCExchangeOptions) (*PaymentTotals, error) {
+	var sorted []Payment
+	for _, payment := range e.filter(payments) {
+		if payment.Amount.Currency == CurrencyRUB {
+			sorted = append(sorted, payment)
+		}
+	}
+
+	var received, spent Money
+	for _, payment := range sorted {
+		if payment.Amount.Sum.IsNegative() {
+			spent, _ = spent.Add(payment.Amount.Sum.Abs())
+		} else {
+			received, _ = received.Add(payment.Amount.Sum)
+		}
+	}
+
+	buffered := bufio.NewWriter(writer)
+	marker := func(name string) {
+		_, _ = buffered.Write(encodeWindows1251(name + "\r\n"))
+	}
+	write := func(key, value string) {
+		marker(key + "=" + sanitize1CValue(value))
+	}
+
+	now := time.Now()
+	from, to := e.Filter.From, e.Filter.To
+	if !to.IsZero() {
+		// Конец периода в фильтре не включается в него, а в формате обмена - включается.
+		to = to.Add(-time.Nanosecond)
+	}
+
+	if len(sorted) > 0 {
+		if from.IsZero() {
+			from = sorted[0].CreatedAt
+		}
+		if e.Filter.To.IsZero() {
+			to = sorted[len(sorted)-1].CreatedAt
+		}
+	}
+
+	marker("1CClientBankExchange")
+	write("ВерсияФормата", "1.03")
+	write("Кодировка", "Windows")
+	write("Отправитель", options.Sender)
+	write("Получатель", options.Recipient)
+	write("ДатаСоздания", formatDate1C(now))
+	write("ВремяСоздания", now.Format("15:04:05"))
+	write("ДатаНачала", formatDate1C(from))
+	write("ДатаКонца", formatDate1C(to))
+	write("РасчСчет", options.Account)
+
+	marker("СекцияРасчСчет")
+	write("ДатаНачала", formatDate1C(from))
+	write("ДатаКонца", formatDate1C(to))
+	write("РасчСчет", options.Account)
+	write("ВсегоПоступило", received.Decimal())
+	write("ВсегоСписано", spent.Decimal())
+	marker("КонецРасчСчет")
+
+	totals, err := e.exportFiltered(sorted, func(payment *Payment, email string) error {
+		client := email
+		if client == "" {
+			client = fmt.Sprintf("Пользователь #%d", payment.UserID)
+		}
+
+		date := formatDate1C(payment.CreatedAt)
+		write("СекцияДокумент", "Платежное поручение")
+		write("Номер", payment.ID)
+		write("Дата", date)
+		write("Сумма", payment.Amount.Sum.Abs().Decimal())
+
+		if payment.Amount.Sum.IsNegative() {
+			write("ПлательщикСчет", options.Account)
+			write("Плательщик", options.CompanyName)
+			write("ПлательщикИНН", options.CompanyINN)
+			write("Получатель", client)
+			write("ДатаСписано", date)
+		} else {
+			write("Плательщик", client)
+			write("ПолучательСчет", options.Account)
+			write("Получатель", options.CompanyName)
+			write("ПолучательИНН", options.CompanyINN)
+			write("ДатаПоступило", date)
+		}
+
+		write("НазначениеПлатежа", describePayment(payment))
+		marker("КонецДокумента")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	marker("КонецФайла")
+	err = buffered.Flush()
+	if err != nil {
+		return nil, fmt.Errorf("writing exchange file: %s", err)
+	}
+
+	return totals, nil
+}
+
+func (e *PaymentExporter) export(payments []Payment, write func(payment *Payment, email string) error) (*PaymentTotals, error) {
+	return e.exportFiltered(e.filter(payments), write)
+}
+
+func (e *PaymentExporter) exportFiltered(payments []Payment, write func(payment *Payment, email string) error) (*PaymentTotals, error) {
+	totals := newPaymentTotals()
+
+	for i := range payments {
+		payment := &payments[i]
+
+		email, err := e.getUserEmail(payment.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = write(payment, email)
+		if err != nil {
+			return nil, fmt.Errorf("writing payment %s: %s", payment.ID, err)
+		}
+
+		totals.add(payment)
+	}
+
+	return totals, nil
+}
+
+func (e *PaymentExporter) filter(payments []Payment) []Payment {
+	filtered := make([]Payment, 0, len(payments))
+	for i := range payments {
+		if e.Filter.Match(&payments[i]) {
+			filtered = append(filtered, payments[i])
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].CreatedAt.Before(filtered[j].CreatedAt)
+	})
+
+	return filtered
+}
+
+func (e *PaymentExporter) getUserEmail(userID int64) (string, error) {
+	if e.Client == nil {
+		return "", nil
+	}
+
+	if email, ok := e.emails[userID]; ok {
+		return email, nil
+	}
+
+	user, err := e.Client.GetUser(userID)
+	if err != nil {
+		return "", fmt.Errorf("getting user %d: %s", userID, err)
+	}
+
+	if e.emails == nil {
+		e.emails = make(map[int64]string)
+	}
+
+	e.emails[userID] = user.Email
+	return user.Email, nil
+}
+
+func describePayment(payment *Payment) string {
+	if payment.Description.Valid && payment.Description.String != "" {
+		return payment.Description.String
+	}
+
+	switch payment.Source.Type {
+	case PaymentSourceTopUp:
+		return "Пополнение баланса"
+	case PaymentSourceServerService:
+		return "Оплата игрового сервера"
+	case PaymentSourceRefund:
+		return "Возврат средств"
+	default:
+		return string(payment.Source.Type)
+	}
+}
+
+// sanitize1CValue заменяет управляющие символы в значении реквизита пробелами и схлопывает повторяющиеся пробелы,
+// чтобы значение не могло добавить в файл обмена новые строки.
+func sanitize1CValue(value string) string {
+	if strings.IndexFunc(value, unicode.IsControl) < 0 {
+		return value
+	}
+
+	return strings.Join(strings.Fields(strings.Map(func(char rune) rune {
+		if unicode.IsControl(char) {
+			return ' '
+		}
+
+		return char
+	}, value)), " ")
+}
+
+func formatDate1C(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.Format("02.01.2006")
+}
+
+// encodeWindows1251 перекодирует текст из UTF-8 в Windows-1251. Символы, отсутствующие в кодировке,
+// заменяются на "?".
+func encodeWindows1251(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, char := range text {
+		encoded = append(encoded, encodeWindows1251Rune(char))
+	}
+
+	return encoded
+}
+
+func encodeWindows1251Rune(char rune) byte {
+	switch {
+	case char < 0x80:
+		return byte(char)
+	case char >= 'А' && char <= 'я':
+		return byte(char - 'А' + 0xC0)
+	case char == 'Ё':
+		return 0xA8
+	case char == 'ё':
+		return 0xB8
+	case char == '№':
+		return 0xB9
+	case char == '«':
+		return 0xAB
+	case char == '»':
+		return 0xBB
+	case char == '—':
+		return 0x97
+	case char == '–':
+		return 0x96
+	default:
+		return '?'
+	}
+}
@@ -0,0 +1,84 @@
+package superhub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestPaymentExporter_ExportCSV(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	payments := []Payment{
+		newTestPayment("b", -2500, PaymentSourceServerService, true, start.Add(48*time.Hour)),
+		newTestPayment("a", 10000, PaymentSourceTopUp, true, start.Add(24*time.Hour)),
+		newTestPayment("c", 5000, PaymentSourceTopUp, true, start.Add(-24*time.Hour)),
+		newTestPayment("d", 7000, PaymentSourceReferral, true, start.Add(72*time.Hour)),
+	}
+
+	exporter := NewPaymentExporter(nil, PaymentFilter{
+		From:    start,
+		Sources: []PaymentSourceType{PaymentSourceTopUp, PaymentSourceServerService},
+	})
+
+	var buffer bytes.Buffer
+	totals, err := exporter.ExportCSV(&buffer, payments)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, lines[1], "a,2024-03-02T00:00:00Z,1,,100.00,RUB,TOP_UP,,PRODUCTION,true,")
+	assert.Equal(t, lines[2], "b,2024-03-03T00:00:00Z,1,,-25.00,RUB,SERVER_SERVICE,,PRODUCTION,true,")
+
+	assert.Equal(t, totals.Count, 2)
+	assert.Equal(t, totals.ByCurrency[CurrencyRUB], NewMoney(7500, CurrencyRUB))
+	assert.Equal(t, totals.BySource[PaymentSourceTopUp][CurrencyRUB], NewMoney(10000, CurrencyRUB))
+}
+
+func TestPaymentExporter_Export1C(t *testing.T) {
+	payments := []Payment{
+		newTestPayment("a", 10000, PaymentSourceTopUp, true, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)),
+	}
+
+	var buffer bytes.Buffer
+	_, err := NewPaymentExporter(nil, PaymentFilter{}).Export1C(&buffer, payments, OneCExchangeOptions{Account: "40702810000000000001"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, bytes.HasPrefix(buffer.Bytes(), []byte("1CClientBankExchange\r\n")), true)
+	assert.Equal(t, bytes.Contains(buffer.Bytes(), encodeWindows1251("ВсегоПоступило=100.00\r\n")), true)
+	assert.Equal(t, bytes.HasSuffix(buffer.Bytes(), encodeWindows1251("КонецФайла\r\n")), true)
+	assert.Equal(t, encodeWindows1251("Ёж №1"), []byte{0xA8, 0xE6, ' ', 0xB9, '1'})
+}
+
+func TestPaymentExporter_Export1CSanitizesValues(t *testing.T) {
+	payment := newTestPayment("a", 10000, PaymentSourceTopUp, true, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+	payment.Description = null.StringFrom("Заказ 1\r\nСумма=1000000.00\nКонецДокумента\x00")
+
+	var buffer bytes.Buffer
+	_, err := NewPaymentExporter(nil, PaymentFilter{}).Export1C(&buffer, []Payment{payment}, OneCExchangeOptions{
+		Account:     "40702810000000000001",
+		CompanyName: "ООО\t\"Хостинг\"",
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	lines := bytes.Split(buffer.Bytes(), []byte("\r\n"))
+	assert.Equal(t, bytes.Contains(buffer.Bytes(), encodeWindows1251("НазначениеПлатежа=Заказ 1 Сумма=1000000.00 КонецДокумента\r\n")), true)
+	assert.Equal(t, bytes.Contains(buffer.Bytes(), encodeWindows1251("Получатель=ООО \"Хостинг\"\r\n")), true)
+
+	for _, line := range lines {
+		assert.Equal(t, bytes.HasPrefix(line, encodeWindows1251("Сумма=1000000.00")), false)
+		assert.Equal(t, bytes.ContainsAny(line, "\r\n\t\x00"), false)
+	}
+}
@@ -0,0 +1,231 @@
+package superhub
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// DefaultForecastHorizon - период, на который строится прогноз баланса, если он не задан явно.
+const DefaultForecastHorizon = 365 * 24 * time.Hour
+
+// ServerForecast - прогноз списаний за один сервер.
+type ServerForecast struct {
+	// Сервер, для которого построен прогноз.
+	Server Server `json:"server"`
+
+	// Сумма одного списания с учётом скидки (см. ServerCost.Effective). Для серверов, замороженных пользователем, -
+	// стоимость заморозки.
+	Charge Money `json:"charge"`
+
+	// Период списаний.
+	Period BillingPeriod `json:"period"`
+
+	// Дата ближайшего списания. Пустая, если списаний в пределах прогноза не будет.
+	NextChargeAt null.Time `json:"nextChargeAt"`
+
+	// Дата, когда на балансе не хватит средств для очередного списания и сервер будет заблокирован.
+	// Пустая, если в пределах прогноза средств хватит.
+	BlockedAt null.Time `json:"blockedAt"`
+}
+
+// BalanceForecast - прогноз расходования баланса пользователя.
+type BalanceForecast struct {
+	// Идентификатор пользователя.
+	UserID int64 `json:"userId"`
+
+	// Баланс пользователя на момент построения прогноза.
+	Balance Money `json:"balance"`
+
+	// Средний расход в день по всем серверам пользователя (ежемесячные списания делятся на DaysPerMonth).
+	DailyCost Money `json:"dailyCost"`
+
+	// Средний расход в месяц по всем серверам пользователя.
+	MonthlyCost Money `json:"monthlyCost"`
+
+	// Дата первого списания, на которое не хватит средств на балансе. Пустая, если средств хватит до конца прогноза.
+	ExhaustionDate null.Time `json:"exhaustionDate"`
+
+	// Баланс после последнего списания в пределах прогноза.
+	RemainingBalance Money `json:"remainingBalance"`
+
+	// Прогнозы по серверам. Серверы, которые будут заблокированы, идут первыми в порядке даты блокировки.
+	Servers []ServerForecast `json:"servers"`
+
+	// Дата окончания прогноза.
+	Until time.Time `json:"until"`
+}
+
+// ForecastBalance строит прогноз расходования баланса пользователя user на серверы servers начиная с момента now
+// на период horizon без обращения к API. Списания моделируются в хронологическом порядке: ежедневные - каждые сутки
+// с момента создания сервера, ежемесячные - в тот же день месяца, в который сервер был создан. Если очередное
+// списание больше остатка на балансе, сервер считается заблокированным и больше не учитывается.
+//
+// Серверы с единоразовой оплатой и серверы, срок действия которых истекает до очередного списания, не учитываются.
+func ForecastBalance(user *User, servers []Server, now time.Time, horizon time.Duration) (*BalanceForecast, error) {
+	if horizon <= 0 {
+		horizon = DefaultForecastHorizon
+	}
+
+	until := now.Add(horizon)
+	forecast := &BalanceForecast{
+		UserID:           user.ID,
+		Balance:          user.Balance.Sum,
+		DailyCost:        NewMoney(0, user.Balance.Sum.Currency),
+		MonthlyCost:      NewMoney(0, user.Balance.Sum.Currency),
+		RemainingBalance: user.Balance.Sum,
+		Until:            until,
+	}
+
+	forecast.Servers = make([]ServerForecast, len(servers))
+	for i := range servers {
+		server := &servers[i]
+		charge, err := server.Cost.Effective()
+		if err != nil {
+			return nil, fmt.Errorf("server %d: %w", server.ID, err)
+		}
+
+		if server.IsFrozenByUser() {
+			charge = server.Cost.Freeze.Money
+		}
+
+		forecast.Servers[i] = ServerForecast{Server: *server, Charge: charge, Period: server.Billing.Period}
+		if next, ok := nextServerCharge(server, now, until); ok {
+			forecast.Servers[i].NextChargeAt = null.TimeFrom(next)
+		}
+
+		var daily, monthly Money
+		switch server.Billing.Period {
+		case BillingPeriodDaily:
+			daily = charge
+			monthly, err = charge.Mul(DaysPerMonth, RoundHalfUp)
+		case BillingPeriodMonthly:
+			daily, err = charge.Mul(1.0/DaysPerMonth, RoundHalfUp)
+			monthly = charge
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("server %d: %w", server.ID, err)
+		}
+
+		forecast.DailyCost, err = forecast.DailyCost.Add(daily)
+		if err != nil {
+			return nil, fmt.Errorf("server %d: %w", server.ID, err)
+		}
+
+		forecast.MonthlyCost, _ = forecast.MonthlyCost.Add(monthly)
+	}
+
+	// Даты очередных списаний по серверам, которые ещё не заблокированы.
+	pending := make(map[int]time.Time)
+	for i := range forecast.Servers {
+		if forecast.Servers[i].NextChargeAt.Valid {
+			pending[i] = forecast.Servers[i].NextChargeAt.Time
+		}
+	}
+
+	balance := forecast.Balance
+	for len(pending) > 0 {
+		current := -1
+		for i, at := range pending {
+			if current == -1 || at.Before(pending[current]) || at.Equal(pending[current]) && i < current {
+				current = i
+			}
+		}
+
+		at := pending[current]
+		server := &forecast.Servers[current]
+
+		remaining, err := balance.Sub(server.Charge)
+		if err != nil {
+			return nil, fmt.Errorf("server %d: %w", server.Server.ID, err)
+		}
+
+		if remaining.IsNegative() {
+			server.BlockedAt = null.TimeFrom(at)
+			if !forecast.ExhaustionDate.Valid {
+				forecast.ExhaustionDate = null.TimeFrom(at)
+			}
+
+			delete(pending, current)
+			continue
+		}
+
+		balance = remaining
+		if next, ok := nextServerCharge(&server.Server, at, until); ok {
+			pending[current] = next
+		} else {
+			delete(pending, current)
+		}
+	}
+
+	forecast.RemainingBalance = balance
+	sort.SliceStable(forecast.Servers, func(i, j int) bool {
+		left, right := forecast.Servers[i].BlockedAt, forecast.Servers[j].BlockedAt
+		if left.Valid != right.Valid {
+			return left.Valid
+		}
+
+		return left.Valid && left.Time.Before(right.Time)
+	})
+
+	return forecast, nil
+}
+
+// nextServerCharge возвращает дату первого списания за сервер строго после after, если оно произойдёт не позже until.
+func nextServerCharge(server *Server, after, until time.Time) (time.Time, bool) {
+	var next time.Time
+	switch server.Billing.Period {
+	case BillingPeriodDaily:
+		days := int(after.Sub(server.CreatedAt)/(24*time.Hour)) + 1
+		if days < 1 {
+			days = 1
+		}
+		next = server.CreatedAt.AddDate(0, 0, days)
+		for !next.After(after) {
+			next = next.AddDate(0, 0, 1)
+		}
+	case BillingPeriodMonthly:
+		months := (after.Year()-server.CreatedAt.Year())*12 + int(after.Month()-server.CreatedAt.Month())
+		if months < 1 {
+			months = 1
+		}
+
+		next = server.CreatedAt.AddDate(0, months, 0)
+		if !next.After(after) {
+			next = server.CreatedAt.AddDate(0, months+1, 0)
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if next.After(until) || server.IsTemporary() && next.After(server.ExpiresAt.Time) {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+// ForecastBalance строит прогноз расходования баланса данного пользователя на его серверы (см. ForecastBalance).
+func (u *User) ForecastBalance(client *Client, horizon time.Duration) (*BalanceForecast, error) {
+	servers, err := u.GetOwnedServers(client, false)
+	if err != nil {
+		return nil, fmt.Errorf("getting owned servers: %s", err)
+	}
+
+	return ForecastBalance(u, *servers, time.Now(), horizon)
+}
+
+// ForecastUserBalance строит прогноз расходования баланса пользователя с заданным идентификатором на его серверы
+// (см. ForecastBalance).
+func (c *Client) ForecastUserBalance(userID int64, horizon time.Duration) (*BalanceForecast, error) {
+	user, err := c.GetUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %s", err)
+	}
+
+	return user.ForecastBalance(c, horizon)
+}
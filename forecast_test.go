@@ -0,0 +1,77 @@
+package superhub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestForecastBalance(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	servers := []Server{
+		{
+			ID:        1,
+			Cost:      ServerCost{Base: NewMoney(1000, CurrencyRUB)},
+			Billing:   ServerBillingConfig{Period: BillingPeriodDaily},
+			CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        2,
+			Cost:      ServerCost{Base: NewMoney(30000, CurrencyRUB), Freeze: NewNullMoney(NewMoney(3000, CurrencyRUB))},
+			Billing:   ServerBillingConfig{Period: BillingPeriodMonthly},
+			FrozenAt:  null.TimeFrom(now.Add(-time.Hour)),
+			CreatedAt: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	user := &User{ID: 1, Balance: PaymentAmount{Sum: NewMoney(10000, CurrencyRUB), Currency: CurrencyRUB}}
+	forecast, err := ForecastBalance(user, servers, now, 30*24*time.Hour)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, forecast.DailyCost, NewMoney(1100, CurrencyRUB))
+	assert.Equal(t, forecast.MonthlyCost, NewMoney(33000, CurrencyRUB))
+
+	// Ежедневные списания по 10 рублей с 11 июня, заморозка за 30 рублей 15 июня: на 7 ежедневных списаний
+	// средств хватит, а восьмое (18 июня) уже не пройдёт.
+	assert.Equal(t, forecast.ExhaustionDate, null.TimeFrom(time.Date(2024, 6, 18, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, forecast.Servers[0].Server.ID, int64(1))
+	assert.Equal(t, forecast.Servers[0].BlockedAt, forecast.ExhaustionDate)
+	assert.Equal(t, forecast.Servers[1].BlockedAt.Valid, false)
+	assert.Equal(t, forecast.RemainingBalance, NewMoney(0, CurrencyRUB))
+}
+
+func TestForecastBalance_Discount(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	servers := []Server{
+		{
+			ID: 1,
+			Cost: ServerCost{
+				Base:     NewMoney(1000, CurrencyRUB),
+				Discount: &Discount{Type: DiscountPercentage, Percent: 50},
+			},
+			Billing:   ServerBillingConfig{Period: BillingPeriodDaily},
+			CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	user := &User{ID: 1, Balance: PaymentAmount{Sum: NewMoney(2000, CurrencyRUB), Currency: CurrencyRUB}}
+	forecast, err := ForecastBalance(user, servers, now, 30*24*time.Hour)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, forecast.Servers[0].Charge, NewMoney(500, CurrencyRUB))
+	assert.Equal(t, forecast.DailyCost, NewMoney(500, CurrencyRUB))
+	assert.Equal(t, forecast.MonthlyCost, NewMoney(15000, CurrencyRUB))
+
+	// Со скидкой ежедневные списания составляют 5 рублей, поэтому 20 рублей хватит на 4 списания с 11 по 14 июня,
+	// а без скидки - только на 2.
+	assert.Equal(t, forecast.ExhaustionDate, null.TimeFrom(time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, forecast.RemainingBalance, NewMoney(0, CurrencyRUB))
+}
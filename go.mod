@@ -1,9 +1,14 @@
 module github.com/superhub-host/hosting-go
 
-go 1.19
+go 1.21
 
 require github.com/google/uuid v1.3.0
 
 require github.com/go-playground/assert/v2 v2.2.0
 
 require gopkg.in/guregu/null.v4 v4.0.0
+
+require (
+	golang.org/x/image v0.24.0
+	golang.org/x/text v0.22.0 // indirect
+)
@@ -0,0 +1,116 @@
+package superhub
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Заголовки, которыми запросы сотрудника отмечаются как выполненные от имени другого пользователя.
+const (
+	// ActAsUserHeader содержит идентификатор пользователя, от имени которого выполняется запрос.
+	ActAsUserHeader = "X-Act-As-User"
+
+	// ImpersonationReasonHeader содержит причину работы от имени пользователя, закодированную как параметр URL.
+	ImpersonationReasonHeader = "X-Impersonation-Reason"
+)
+
+// ImpersonationRecord - запись о запросе, выполненном от имени другого пользователя.
+type ImpersonationRecord struct {
+	// Идентификатор пользователя, от имени которого выполнен запрос.
+	UserID int64
+
+	// Причина работы от имени пользователя.
+	Reason string
+
+	// Метод запроса.
+	Method string
+
+	// Адрес запроса.
+	URL string
+
+	// Идентификатор запроса (см. RequestIDHeader).
+	RequestID string
+
+	// Время отправки запроса.
+	Time time.Time
+}
+
+// Impersonation - учётные данные сотрудника, которые выполняют запросы от имени другого пользователя. Запросы
+// авторизуются учётными данными сотрудника и отмечаются заголовком ActAsUserHeader, а ссылки на текущего
+// пользователя (CurrentUserReference) в методах клиента заменяются на идентификатор пользователя UserID,
+// поэтому, например, GetCurrentUser получает этого пользователя.
+//
+// API проверяет право сотрудника работать от имени пользователей и сохраняет такие запросы в журнале аудита вместе
+// с причиной Reason.
+type Impersonation struct {
+	// Учётные данные сотрудника.
+	Credentials Credentials
+
+	// Идентификатор пользователя, от имени которого выполняются запросы.
+	UserID int64
+
+	// Причина работы от имени пользователя, например номер обращения в поддержку.
+	Reason string
+
+	// Если задана, вызывается один раз для каждого запроса клиента, например для записи в локальный журнал аудита.
+	// Повторные отправки запроса (после обновления учётных данных, ошибки сервера или ошибки 429) не записываются
+	// отдельно: их можно связать с записью по идентификатору запроса RequestID. Те же сведения содержат
+	// Response и RequestError (см. Response.ImpersonatedUserID).
+	Audit func(record ImpersonationRecord)
+}
+
+// NewImpersonation создаёт учётные данные, которые выполняют запросы от имени пользователя userID.
+func NewImpersonation(credentials Credentials, userID int64, reason string) *Impersonation {
+	return &Impersonation{Credentials: credentials, UserID: userID, Reason: reason}
+}
+
+func (i *Impersonation) AuthorizeRequest(request *http.Request) {
+	if i.Credentials != nil {
+		i.Credentials.AuthorizeRequest(request)
+	}
+
+	request.Header.Set(ActAsUserHeader, strconv.FormatInt(i.UserID, 10))
+	if i.Reason != "" {
+		request.Header.Set(ImpersonationReasonHeader, url.QueryEscape(i.Reason))
+	}
+}
+
+// audit записывает запрос в журнал аудита (см. Audit). Вызывается клиентом один раз перед отправкой запроса,
+// а не при каждой его авторизации.
+func (i *Impersonation) audit(request *http.Request) {
+	if i.Audit == nil {
+		return
+	}
+
+	i.Audit(ImpersonationRecord{
+		UserID:    i.UserID,
+		Reason:    i.Reason,
+		Method:    request.Method,
+		URL:       request.URL.String(),
+		RequestID: request.Header.Get(RequestIDHeader),
+		Time:      time.Now(),
+	})
+}
+
+// Unwrap возвращает учётные данные сотрудника.
+func (i *Impersonation) Unwrap() Credentials {
+	return i.Credentials
+}
+
+// Impersonate возвращает копию клиента, которая выполняет запросы от имени пользователя userID с учётными данными
+// данного клиента (см. Impersonation).
+func (c *Client) Impersonate(userID int64, reason string) *Client {
+	return c.withCredentials(NewImpersonation(c.GetCredentials(), userID, reason))
+}
+
+// getCurrentUserReference возвращает ссылку на текущего пользователя для адресов запросов: идентификатор
+// пользователя, если клиент работает от его имени, иначе CurrentUserReference.
+func (c *Client) getCurrentUserReference() string {
+	if impersonation, ok := c.GetCredentials().(*Impersonation); ok {
+		return strconv.FormatInt(impersonation.UserID, 10)
+	}
+
+	return CurrentUserReference
+}
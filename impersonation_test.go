@@ -0,0 +1,146 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+)
+
+func TestClient_Impersonate(t *testing.T) {
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, received *http.Request) {
+		request = received
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(`{"id": 42}`))
+	}))
+	defer server.Close()
+
+	token := NewPersistentToken(uuid.MustParse("0b9f6b52-5b4e-4cf4-9a58-0c9f3a1d4e21"), "secret")
+	client := NewClientWithCredentials(token)
+	client.BaseURL = server.URL
+
+	impersonated := client.Impersonate(42, "обращение #17")
+
+	var records []ImpersonationRecord
+	impersonated.Credentials.(*Impersonation).Audit = func(record ImpersonationRecord) {
+		records = append(records, record)
+	}
+
+	user, err := impersonated.GetCurrentUser()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, user.ID, int64(42))
+	assert.Equal(t, request.URL.Path, "/users/42")
+	assert.Equal(t, request.Header.Get("X-Access-Token"), "secret")
+	assert.Equal(t, request.Header.Get(ActAsUserHeader), "42")
+	assert.Equal(t, request.Header.Get(ImpersonationReasonHeader), "%D0%BE%D0%B1%D1%80%D0%B0%D1%89%D0%B5%D0%BD%D0%B8%D0%B5+%2317")
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].Method, http.MethodGet)
+
+	// Исходный клиент продолжает работать от имени владельца учётных данных.
+	_, err = client.GetCurrentUser()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, request.URL.Path, "/users/@self")
+	assert.Equal(t, request.Header.Get(ActAsUserHeader), "")
+}
+
+func TestClient_ImpersonateResponseMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		if request.URL.Path == "/users/42/servers" {
+			writer.WriteHeader(http.StatusForbidden)
+			_, _ = writer.Write([]byte(`{"error": "Forbidden", "status": 403}`))
+			return
+		}
+
+		_, _ = writer.Write([]byte(`{"id": 42}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, Credentials: EmptyCredentials{}}
+	impersonated := client.Impersonate(42, "обращение #17")
+
+	// Ответ и ошибка позволяют записать запрос в журнал аудита без функции Impersonation.Audit.
+	response, err := InvokeEndpointWithResponse[User](impersonated, http.MethodGet, "/users/42", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, response.ImpersonatedUserID, int64(42))
+	assert.Equal(t, response.ImpersonationReason, "обращение #17")
+
+	_, err = impersonated.GetOwnedServers(42, false)
+
+	var requestError *RequestError
+	assert.Equal(t, errors.As(err, &requestError), true)
+	assert.Equal(t, requestError.StatusCode, http.StatusForbidden)
+	assert.Equal(t, requestError.ImpersonatedUserID, int64(42))
+	assert.Equal(t, requestError.ImpersonationReason, "обращение #17")
+
+	// Запросы от имени владельца учётных данных не содержат сведений о работе от имени пользователя.
+	response, err = InvokeEndpointWithResponse[User](client, http.MethodGet, "/users/42", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, response.ImpersonatedUserID, int64(0))
+	assert.Equal(t, response.ImpersonationReason, "")
+}
+
+func TestImpersonation_AuditOncePerRequest(t *testing.T) {
+	var requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		switch request.URL.Path {
+		case "/sessions/refresh":
+			_, _ = fmt.Fprintf(writer, `{"accessToken": "fresh", "refreshToken": "refresh", "expiresAt": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		default:
+			requestIDs = append(requestIDs, request.Header.Get(RequestIDHeader))
+			if request.Header.Get("Authorization") != "Bearer fresh" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				_, _ = writer.Write([]byte(`{"error": "Unauthorized", "status": 401}`))
+				return
+			}
+
+			_, _ = writer.Write([]byte(`{"id": 42}`))
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	session := NewSession(client, SessionTokens{AccessToken: "revoked", RefreshToken: "refresh", ExpiresAt: time.Now().Add(time.Hour)})
+
+	var records []ImpersonationRecord
+	impersonation := NewImpersonation(session, 42, "")
+	impersonation.Audit = func(record ImpersonationRecord) {
+		records = append(records, record)
+	}
+
+	// Запрос отклоняется с ошибкой 401 и повторяется после обновления сессии, но в журнал аудита попадает один раз.
+	_, err := client.withCredentials(impersonation).GetCurrentUser()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, len(requestIDs), 2)
+	assert.Equal(t, requestIDs[0], requestIDs[1])
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].RequestID, requestIDs[0])
+	assert.Equal(t, records[0].URL, server.URL+"/users/42")
+}
@@ -0,0 +1,414 @@
+package superhub
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DocumentKind - вид финансового документа, формируемого по платежу.
+type DocumentKind string
+
+const (
+	// DocumentInvoice - счёт за услуги. Формируется по списаниям за игровые серверы (PaymentSourceServerService).
+	DocumentInvoice DocumentKind = "INVOICE"
+
+	// DocumentReceipt - квитанция о пополнении баланса. Формируется по пополнениям (PaymentSourceTopUp).
+	DocumentReceipt DocumentKind = "RECEIPT"
+)
+
+// DocumentLocale - язык, на котором формируется документ.
+type DocumentLocale string
+
+const (
+	DocumentLocaleRussian DocumentLocale = "ru"
+	DocumentLocaleEnglish DocumentLocale = "en"
+)
+
+// DefaultDocumentLocale - язык документа, если он не задан явно (см. DocumentRenderer.Locale).
+const DefaultDocumentLocale = DocumentLocaleRussian
+
+// CompanyRequisites - реквизиты организации, от имени которой формируются документы.
+type CompanyRequisites struct {
+	// Наименование организации.
+	Name string
+
+	// Юридический адрес.
+	Address string
+
+	// ИНН.
+	INN string
+
+	// КПП.
+	KPP string
+
+	// ОГРН или ОГРНИП.
+	OGRN string
+
+	// Наименование банка.
+	BankName string
+
+	// БИК банка.
+	BIK string
+
+	// Расчётный счёт.
+	Account string
+
+	// Корреспондентский счёт банка.
+	CorrespondentAccount string
+
+	// Контактный адрес электронной почты.
+	Email string
+}
+
+// DocumentRenderer формирует PDF документы по платежам: счета по списаниям за серверы и квитанции о пополнении
+// баланса. Документ формируется без внешних программ; если содержимое не помещается на одну страницу, оно
+// переносится на следующие.
+//
+// Текст выводится шрифтами Go, которые встраиваются в документ и содержат кириллические глифы, поэтому документ
+// на любом из поддерживаемых языков одинаково отображается во всех программах просмотра и при печати.
+type DocumentRenderer struct {
+	// Реквизиты организации.
+	Company CompanyRequisites
+
+	// Язык документа. Если не задан, используется DefaultDocumentLocale.
+	Locale DocumentLocale
+
+	// Функция, присваивающая документу номер. Если не задана, номером документа является идентификатор платежа.
+	Numbering func(kind DocumentKind, payment *Payment) string
+}
+
+// NewSequentialNumbering создаёт функцию нумерации документов (см. DocumentRenderer.Numbering), которая присваивает
+// документам последовательные номера начиная с next, отдельно для каждого вида документа. Номер состоит из префикса
+// prefix и порядкового номера. Функция безопасна для использования из нескольких горутин.
+func NewSequentialNumbering(prefix string, next int64) func(kind DocumentKind, payment *Payment) string {
+	var mutex sync.Mutex
+	counters := make(map[DocumentKind]int64)
+
+	return func(kind DocumentKind, payment *Payment) string {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if _, ok := counters[kind]; !ok {
+			counters[kind] = next
+		}
+
+		number := counters[kind]
+		counters[kind]++
+		return fmt.Sprintf("%s%d", prefix, number)
+	}
+}
+
+// GetDocumentKind возвращает вид документа, который формируется по платежу.
+func GetDocumentKind(payment *Payment) (DocumentKind, error) {
+	switch payment.Source.Type {
+	case PaymentSourceServerService:
+		return DocumentInvoice, nil
+	case PaymentSourceTopUp:
+		return DocumentReceipt, nil
+	default:
+		return "", fmt.Errorf("no document for payment source %q", payment.Source.Type)
+	}
+}
+
+var documentStrings = map[DocumentLocale]map[string]string{
+	DocumentLocaleRussian: {
+		"invoice":     "Счёт № %s от %s",
+		"receipt":     "Квитанция № %s от %s",
+		"seller":      "Исполнитель",
+		"buyer":       "Заказчик",
+		"recipient":   "Получатель",
+		"payer":       "Плательщик",
+		"address":     "Адрес",
+		"inn":         "ИНН",
+		"kpp":         "КПП",
+		"ogrn":        "ОГРН",
+		"bank":        "Банк",
+		"bik":         "БИК",
+		"account":     "Р/с",
+		"corr":        "К/с",
+		"email":       "Эл. почта",
+		"user":        "Пользователь",
+		"item":        "Наименование",
+		"quantity":    "Кол-во",
+		"amount":      "Сумма",
+		"total":       "Итого",
+		"server":      "Оплата услуг хостинга игрового сервера",
+		"topUp":       "Пополнение баланса лицевого счёта",
+		"payment":     "Платёж",
+		"pending":     "Платёж не завершён",
+		"noVat":       "Без НДС",
+		"page":        "Страница %d из %d",
+		"dateFormat":  "02.01.2006",
+		"currencyRUB": "руб.",
+	},
+	DocumentLocaleEnglish: {
+		"invoice":     "Invoice No. %s dated %s",
+		"receipt":     "Receipt No. %s dated %s",
+		"seller":      "Seller",
+		"buyer":       "Customer",
+		"recipient":   "Recipient",
+		"payer":       "Payer",
+		"address":     "Address",
+		"inn":         "TIN",
+		"kpp":         "KPP",
+		"ogrn":        "OGRN",
+		"bank":        "Bank",
+		"bik":         "BIC",
+		"account":     "Account",
+		"corr":        "Corr. account",
+		"email":       "Email",
+		"user":        "User",
+		"item":        "Description",
+		"quantity":    "Qty",
+		"amount":      "Amount",
+		"total":       "Total",
+		"server":      "Game server hosting services",
+		"topUp":       "Account balance top-up",
+		"payment":     "Payment",
+		"pending":     "Payment is not completed",
+		"noVat":       "VAT exempt",
+		"page":        "Page %d of %d",
+		"dateFormat":  "Jan 2, 2006",
+		"currencyRUB": "RUB",
+	},
+}
+
+// Поля страницы и параметры текста в пунктах.
+const (
+	documentMargin     = 50.0
+	documentFontSize   = 10.0
+	documentLineHeight = 14.0
+)
+
+// documentLayout размещает текст на страницах документа сверху вниз, добавляя новые страницы по мере необходимости.
+type documentLayout struct {
+	document *pdfDocument
+	page     *pdfPage
+	y        float64
+
+	// Вызывается после добавления каждой новой страницы, кроме первой. Например, чтобы повторить заголовок таблицы.
+	onNewPage func()
+}
+
+func newDocumentLayout() (*documentLayout, error) {
+	document, err := newPDFDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	layout := &documentLayout{document: document}
+	layout.page = layout.document.addPage()
+	layout.y = pdfPageHeight - documentMargin
+	return layout, nil
+}
+
+// reserve переносит вывод на новую страницу, если на текущей осталось меньше height пунктов.
+func (l *documentLayout) reserve(height float64) {
+	if l.y-height >= documentMargin+documentLineHeight {
+		return
+	}
+
+	l.page = l.document.addPage()
+	l.y = pdfPageHeight - documentMargin
+
+	if l.onNewPage != nil {
+		l.onNewPage()
+	}
+}
+
+func (l *documentLayout) paragraph(text string, size float64, bold bool) {
+	for _, line := range l.document.font(bold).wrapText(text, size, pdfPageWidth-2*documentMargin) {
+		l.reserve(size * 1.4)
+		l.y -= size * 1.4
+		l.page.text(documentMargin, l.y, size, bold, line)
+	}
+}
+
+func (l *documentLayout) space(height float64) {
+	l.y -= height
+}
+
+func (l *documentLayout) rule() {
+	l.reserve(documentLineHeight / 2)
+	l.y -= documentLineHeight / 2
+	l.page.line(documentMargin, l.y, pdfPageWidth-documentMargin, l.y, 0.5)
+}
+
+// Render формирует документ по платежу payment пользователя user и записывает его в writer. Вид документа
+// определяется источником платежа (см. GetDocumentKind).
+func (r *DocumentRenderer) Render(writer io.Writer, payment *Payment, user *User) error {
+	kind, err := GetDocumentKind(payment)
+	if err != nil {
+		return err
+	}
+
+	return r.RenderKind(writer, kind, payment, user)
+}
+
+// RenderKind формирует документ вида kind по платежу payment пользователя user и записывает его в writer.
+func (r *DocumentRenderer) RenderKind(writer io.Writer, kind DocumentKind, payment *Payment, user *User) error {
+	locale := r.Locale
+	if locale == "" {
+		locale = DefaultDocumentLocale
+	}
+
+	text, ok := documentStrings[locale]
+	if !ok {
+		return fmt.Errorf("unsupported locale: %q", locale)
+	}
+
+	number := payment.ID
+	if r.Numbering != nil {
+		number = r.Numbering(kind, payment)
+	}
+
+	title, item, companyRole, customerRole := text["invoice"], text["server"], text["seller"], text["buyer"]
+	if kind == DocumentReceipt {
+		title, item, companyRole, customerRole = text["receipt"], text["topUp"], text["recipient"], text["payer"]
+	}
+
+	layout, err := newDocumentLayout()
+	if err != nil {
+		return err
+	}
+
+	layout.paragraph(fmt.Sprintf(title, number, payment.CreatedAt.Format(text["dateFormat"])), 16, true)
+	layout.space(documentLineHeight)
+
+	layout.paragraph(companyRole, documentFontSize, true)
+	for _, line := range r.companyLines(text) {
+		layout.paragraph(line, documentFontSize, false)
+	}
+	layout.space(documentLineHeight / 2)
+
+	layout.paragraph(customerRole, documentFontSize, true)
+	layout.paragraph(fmt.Sprintf("%s #%d, %s", text["user"], user.ID, user.Name), documentFontSize, false)
+	layout.paragraph(fmt.Sprintf("%s: %s", text["email"], user.Email), documentFontSize, false)
+	layout.space(documentLineHeight)
+
+	// Колонки таблицы: наименование, количество и сумма, выровненные по правому краю.
+	amountRight := pdfPageWidth - documentMargin
+	quantityRight := amountRight - 110
+	itemWidth := quantityRight - 60 - documentMargin
+
+	tableHeader := func() {
+		layout.y -= documentLineHeight
+		layout.page.text(documentMargin, layout.y, documentFontSize, true, text["item"])
+		layout.page.textRight(quantityRight, layout.y, documentFontSize, true, text["quantity"])
+		layout.page.textRight(amountRight, layout.y, documentFontSize, true, text["amount"])
+		layout.rule()
+	}
+	tableHeader()
+	layout.onNewPage = tableHeader
+
+	description := item
+	if payment.Description.Valid && payment.Description.String != "" {
+		description = fmt.Sprintf("%s (%s)", item, payment.Description.String)
+	}
+	description = fmt.Sprintf("%s. %s %s", description, text["payment"], payment.ID)
+
+	amount := formatDocumentAmount(payment.Amount.Sum.Abs(), locale, text)
+	for i, line := range layout.document.font(false).wrapText(description, documentFontSize, itemWidth) {
+		layout.reserve(documentLineHeight)
+		layout.y -= documentLineHeight
+		layout.page.text(documentMargin, layout.y, documentFontSize, false, line)
+
+		if i == 0 {
+			layout.page.textRight(quantityRight, layout.y, documentFontSize, false, "1")
+			layout.page.textRight(amountRight, layout.y, documentFontSize, false, amount)
+		}
+	}
+
+	layout.onNewPage = nil
+	layout.rule()
+	layout.reserve(documentLineHeight * 3)
+	layout.y -= documentLineHeight
+	layout.page.textRight(amountRight, layout.y, documentFontSize+2, true, fmt.Sprintf("%s: %s", text["total"], amount))
+	layout.y -= documentLineHeight
+	layout.page.textRight(amountRight, layout.y, documentFontSize, false, text["noVat"])
+
+	if !payment.Completed {
+		layout.space(documentLineHeight)
+		layout.paragraph(text["pending"], documentFontSize, true)
+	}
+
+	total := len(layout.document.pages)
+	for i, page := range layout.document.pages {
+		page.textRight(pdfPageWidth-documentMargin, documentMargin/2, 8, false, fmt.Sprintf(text["page"], i+1, total))
+	}
+
+	return layout.document.writeTo(writer)
+}
+
+func (r *DocumentRenderer) companyLines(text map[string]string) []string {
+	company := &r.Company
+	lines := []string{company.Name}
+
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", label, value))
+		}
+	}
+
+	add(text["address"], company.Address)
+
+	var identifiers []string
+	for _, identifier := range [][2]string{{text["inn"], company.INN}, {text["kpp"], company.KPP}, {text["ogrn"], company.OGRN}} {
+		if identifier[1] != "" {
+			identifiers = append(identifiers, fmt.Sprintf("%s %s", identifier[0], identifier[1]))
+		}
+	}
+	if len(identifiers) > 0 {
+		lines = append(lines, strings.Join(identifiers, ", "))
+	}
+
+	add(text["bank"], company.BankName)
+	add(text["bik"], company.BIK)
+	add(text["account"], company.Account)
+	add(text["corr"], company.CorrespondentAccount)
+	add(text["email"], company.Email)
+	return lines
+}
+
+// formatDocumentAmount форматирует сумму по правилам языка документа: "1 234,50 руб." или "RUB 1,234.50".
+func formatDocumentAmount(money Money, locale DocumentLocale, text map[string]string) string {
+	decimal := money.Decimal()
+	integer, fraction := decimal[:len(decimal)-3], decimal[len(decimal)-2:]
+
+	groupSeparator, decimalSeparator := ",", "."
+	if locale == DocumentLocaleRussian {
+		groupSeparator, decimalSeparator = " ", ","
+	}
+
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(groupSeparator)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	currency := money.Currency
+	if label, ok := text["currency"+currency]; ok {
+		currency = label
+	}
+
+	value := grouped.String() + decimalSeparator + fraction
+	if locale == DocumentLocaleRussian {
+		return value + " " + currency
+	}
+
+	return currency + " " + value
+}
+
+// RenderDocument формирует документ по данному платежу (см. DocumentRenderer.Render), получая пользователя через API.
+func (p *Payment) RenderDocument(client *Client, renderer *DocumentRenderer, writer io.Writer) error {
+	user, err := client.GetUser(p.UserID)
+	if err != nil {
+		return fmt.Errorf("getting user: %s", err)
+	}
+
+	return renderer.Render(writer, p, user)
+}
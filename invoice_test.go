@@ -0,0 +1,147 @@
+package superhub
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/go-playground/assert/v2"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"gopkg.in/guregu/null.v4"
+)
+
+var (
+	pdfCMapEntry    = regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>`)
+	pdfTextOperator = regexp.MustCompile(`/F([12]) [\d.]+ Tf [\d.]+ [\d.]+ Td <([0-9A-F]*)> Tj`)
+	pdfFontFile     = regexp.MustCompile(`<< /Length (\d+) /Length1 (\d+) /Filter /FlateDecode >>\nstream\n`)
+)
+
+// extractPDFText восстанавливает текст документа по таблицам ToUnicode его шрифтов, как это делают программы
+// просмотра. Каждая строка результата соответствует одному текстовому оператору.
+func extractPDFText(t *testing.T, document []byte) string {
+	// Таблицы ToUnicode записываются в порядке шрифтов F1 и F2.
+	var cmaps []map[string][]uint16
+	for _, part := range strings.Split(string(document), "endcodespacerange")[1:] {
+		part = part[:strings.Index(part, "endcmap")]
+
+		cmap := make(map[string][]uint16)
+		for _, match := range pdfCMapEntry.FindAllStringSubmatch(part, -1) {
+			for i := 0; i < len(match[2]); i += 4 {
+				unit, _ := strconv.ParseUint(match[2][i:i+4], 16, 16)
+				cmap[match[1]] = append(cmap[match[1]], uint16(unit))
+			}
+		}
+		cmaps = append(cmaps, cmap)
+	}
+	assert.Equal(t, len(cmaps), 2)
+
+	var lines []string
+	for _, match := range pdfTextOperator.FindAllStringSubmatch(string(document), -1) {
+		cmap := cmaps[match[1][0]-'1']
+
+		var units []uint16
+		for i := 0; i < len(match[2]); i += 4 {
+			code, ok := cmap[match[2][i:i+4]]
+			if !ok {
+				t.Errorf("glyph %s is missing from ToUnicode", match[2][i:i+4])
+			}
+			units = append(units, code...)
+		}
+		lines = append(lines, string(utf16.Decode(units)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func TestDocumentRenderer_Render(t *testing.T) {
+	payment := newTestPayment("a1b2", -150000, PaymentSourceServerService, true, time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC))
+	user := &User{ID: 1, Name: "Steve", Email: "steve@example.com"}
+
+	renderer := &DocumentRenderer{
+		Company:   CompanyRequisites{Name: "ООО «Суперхаб»", INN: "7700000000", Account: "40702810000000000001"},
+		Locale:    DocumentLocaleRussian,
+		Numbering: NewSequentialNumbering("SH-", 7),
+	}
+
+	var buffer bytes.Buffer
+	err := renderer.Render(&buffer, &payment, user)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	document := buffer.Bytes()
+	assert.Equal(t, bytes.HasPrefix(document, []byte("%PDF-1.4\n")), true)
+	assert.Equal(t, bytes.HasSuffix(document, []byte("%%EOF\n")), true)
+	assert.Equal(t, bytes.Contains(document, []byte("/Count 1 ")), true)
+
+	text := extractPDFText(t, document)
+	assert.Equal(t, strings.Contains(text, "Счёт № SH-7 от 06.05.2024"), true)
+	assert.Equal(t, strings.Contains(text, "ООО «Суперхаб»"), true)
+	assert.Equal(t, strings.Contains(text, "1 500,00 руб."), true)
+	assert.Equal(t, strings.Contains(text, "?"), false)
+
+	// Длинное описание платежа не помещается на одну страницу и переносится на следующую.
+	payment.Description = null.StringFrom(strings.Repeat("lorem ipsum dolor sit amet ", 400))
+	renderer.Locale = DocumentLocaleEnglish
+
+	buffer.Reset()
+	err = renderer.Render(&buffer, &payment, user)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	document = buffer.Bytes()
+	text = extractPDFText(t, document)
+	assert.Equal(t, strings.Contains(text, "Invoice No. SH-8 dated May 6, 2024"), true)
+	assert.Equal(t, strings.Contains(text, "RUB 1,500.00"), true)
+	assert.Equal(t, strings.Contains(text, "Page 2 of"), true)
+	assert.Equal(t, bytes.Contains(document, []byte("/Count 1 ")), false)
+}
+
+func TestDocumentRenderer_EmbedsFonts(t *testing.T) {
+	payment := newTestPayment("a1b2", 150000, PaymentSourceTopUp, true, time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC))
+	user := &User{ID: 1, Name: "Стив", Email: "steve@example.com"}
+
+	// По умолчанию документ формируется на русском языке.
+	var buffer bytes.Buffer
+	err := (&DocumentRenderer{}).Render(&buffer, &payment, user)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	document := buffer.Bytes()
+	assert.Equal(t, strings.Contains(extractPDFText(t, document), "Квитанция № a1b2 от 06.05.2024"), true)
+	assert.Equal(t, bytes.Count(document, []byte("/Subtype /Type0 ")), 2)
+	assert.Equal(t, bytes.Count(document, []byte("/Subtype /CIDFontType2 ")), 2)
+	assert.Equal(t, bytes.Count(document, []byte("/ToUnicode ")), 2)
+	assert.Equal(t, bytes.Contains(document, []byte("/Type1")), false)
+
+	// В документ встроены файлы шрифтов целиком.
+	matches := pdfFontFile.FindAllSubmatchIndex(document, -1)
+	assert.Equal(t, len(matches), 2)
+	for i, expected := range [][]byte{goregular.TTF, gobold.TTF} {
+		length, _ := strconv.Atoi(string(document[matches[i][2]:matches[i][3]]))
+		reader, err := zlib.NewReader(bytes.NewReader(document[matches[i][1] : matches[i][1]+length]))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		assert.Equal(t, bytes.Equal(data, expected), true)
+	}
+}
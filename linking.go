@@ -0,0 +1,164 @@
+package superhub
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// LinkProvider - внешний сервис, аккаунт в котором может быть привязан к пользователю.
+type LinkProvider string
+
+const (
+	LinkProviderDiscord LinkProvider = "discord"
+	LinkProviderVK      LinkProvider = "vk"
+)
+
+var (
+	// ErrUserNotFound возвращается при поиске пользователя по привязанному аккаунту, если такого пользователя нет.
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrLinkStateMismatch возвращается, если параметр state, полученный от сервиса авторизации, не совпадает
+	// с выданным при начале привязки. Это означает, что запрос на привязку был инициирован не этим пользователем.
+	ErrLinkStateMismatch = errors.New("link state mismatch")
+
+	// ErrLinkDenied возвращается, если пользователь отказался предоставить доступ к своему аккаунту.
+	ErrLinkDenied = errors.New("account link denied")
+)
+
+// AccountLink - начатая привязка аккаунта, ожидающая подтверждения пользователем.
+type AccountLink struct {
+	// Сервис, аккаунт в котором привязывается.
+	Provider LinkProvider `json:"provider"`
+
+	// Адрес страницы авторизации сервиса, на которую необходимо перенаправить пользователя.
+	AuthorizeURL string `json:"authorizeUrl"`
+
+	// Случайное значение, которое сервис авторизации вернёт вместе с кодом. Необходимо сохранить его до завершения
+	// привязки, например в сессии пользователя.
+	State string `json:"state"`
+
+	// Дата, после которой привязку невозможно завершить.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// IsExpired возвращает true, если привязку уже невозможно завершить.
+func (l *AccountLink) IsExpired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// Complete завершает привязку по параметрам state и code, полученным от сервиса авторизации. Вернёт
+// ErrLinkStateMismatch, если state не совпадает с выданным при начале привязки.
+func (l *AccountLink) Complete(client *Client, state, code string) (*AccountLinkResult, error) {
+	if subtle.ConstantTimeCompare([]byte(l.State), []byte(state)) != 1 {
+		return nil, ErrLinkStateMismatch
+	}
+
+	return client.CompleteAccountLink(l.Provider, state, code)
+}
+
+// AccountLinkResult - результат привязки аккаунта.
+type AccountLinkResult struct {
+	// Пользователь после привязки аккаунта.
+	User User `json:"user"`
+
+	// Бонус, начисленный на баланс за привязку. Пустой, если бонус уже был получен ранее
+	// (см. LinkedDiscord.AcquiredLinkBonus и LinkedVK.AcquiredLinkBonus).
+	Bonus NullMoney `json:"bonus"`
+}
+
+// ParseLinkCallback извлекает параметры state и code из адреса, на который сервис авторизации вернул пользователя.
+// Вернёт ErrLinkDenied, если пользователь отказался предоставить доступ.
+func ParseLinkCallback(callbackURL *url.URL) (state, code string, err error) {
+	query := callbackURL.Query()
+	if reason := query.Get("error"); reason != "" {
+		return "", "", fmt.Errorf("%w: %s", ErrLinkDenied, reason)
+	}
+
+	state, code = query.Get("state"), query.Get("code")
+	if state == "" || code == "" {
+		return "", "", errors.New("callback does not contain state or code")
+	}
+
+	return state, code, nil
+}
+
+// accountLinkForm - тело запроса начала привязки аккаунта.
+type accountLinkForm struct {
+	RedirectURL string `json:"redirectUrl"`
+}
+
+// accountLinkCompletionForm - тело запроса завершения привязки аккаунта.
+type accountLinkCompletionForm struct {
+	State string `json:"state"`
+	Code  string `json:"code"`
+}
+
+// BeginAccountLink начинает привязку аккаунта в сервисе provider к текущему пользователю. После авторизации сервис
+// вернёт пользователя на адрес redirectURL с параметрами state и code (см. ParseLinkCallback), которые необходимо
+// передать в AccountLink.Complete или CompleteAccountLink.
+func (c *Client) BeginAccountLink(provider LinkProvider, redirectURL string) (*AccountLink, error) {
+	return InvokeEndpoint[AccountLink](c, http.MethodPost, fmt.Sprintf("/users/%s/links/%s", c.getCurrentUserReference(), provider), accountLinkForm{RedirectURL: redirectURL})
+}
+
+// CompleteAccountLink завершает привязку аккаунта в сервисе provider к текущему пользователю. Вернёт ошибку 409,
+// если аккаунт уже привязан к другому пользователю. Проверку параметра state на стороне клиента выполняет
+// AccountLink.Complete.
+func (c *Client) CompleteAccountLink(provider LinkProvider, state, code string) (*AccountLinkResult, error) {
+	return InvokeEndpoint[AccountLinkResult](c, http.MethodPost, fmt.Sprintf("/users/%s/links/%s/callback", c.getCurrentUserReference(), provider), accountLinkCompletionForm{State: state, Code: code})
+}
+
+// UnlinkAccount отвязывает аккаунт в сервисе provider от текущего пользователя. Полученные бонусы за привязку
+// не списываются, и повторно за привязку они не начисляются.
+func (c *Client) UnlinkAccount(provider LinkProvider) error {
+	return InvokeVoidEndpoint(c, http.MethodDelete, fmt.Sprintf("/users/%s/links/%s", c.getCurrentUserReference(), provider), nil)
+}
+
+// UnlinkUserAccount отвязывает аккаунт в сервисе provider от пользователя с указанным идентификатором.
+func (c *Client) UnlinkUserAccount(userID int64, provider LinkProvider) error {
+	return InvokeVoidEndpoint(c, http.MethodDelete, fmt.Sprintf("/users/%d/links/%s", userID, provider), nil)
+}
+
+// findLinkedUser находит пользователя по условиям поиска options. Найденный пользователь проверяется функцией
+// linked, чтобы не вернуть постороннего пользователя, если API не применило фильтр по привязанному аккаунту.
+func (c *Client) findLinkedUser(options UserListOptions, linked func(user *User) bool) (*User, error) {
+	options.PageSize = 1
+
+	page, err := c.ListUsers(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page.Users) == 0 || !linked(&page.Users[0]) {
+		return nil, ErrUserNotFound
+	}
+
+	return &page.Users[0], nil
+}
+
+// FindUserByDiscordID находит пользователя, к которому привязан аккаунт Discord с указанным идентификатором.
+// Вернёт ErrUserNotFound, если такого пользователя нет.
+func (c *Client) FindUserByDiscordID(discordID string) (*User, error) {
+	if discordID == "" {
+		return nil, errors.New("discord ID is not specified")
+	}
+
+	return c.findLinkedUser(UserListOptions{DiscordID: discordID}, func(user *User) bool {
+		return user.Discord.ID.Valid && user.Discord.ID.String == discordID
+	})
+}
+
+// FindUserByVKID находит пользователя, к которому привязан аккаунт ВК с указанным идентификатором.
+// Вернёт ErrUserNotFound, если такого пользователя нет.
+func (c *Client) FindUserByVKID(vkID int64) (*User, error) {
+	if vkID == 0 {
+		return nil, errors.New("VK ID is not specified")
+	}
+
+	return c.findLinkedUser(UserListOptions{VKID: vkID}, func(user *User) bool {
+		return user.VK.ID.Valid && user.VK.ID.Int64 == vkID
+	})
+}
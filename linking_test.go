@@ -0,0 +1,103 @@
+package superhub
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestParseLinkCallback(t *testing.T) {
+	callbackURL, _ := url.Parse("https://superhub.host/link?state=abc&code=xyz")
+	state, code, err := ParseLinkCallback(callbackURL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, state, "abc")
+	assert.Equal(t, code, "xyz")
+
+	callbackURL, _ = url.Parse("https://superhub.host/link?error=access_denied&state=abc")
+	_, _, err = ParseLinkCallback(callbackURL)
+	assert.Equal(t, errors.Is(err, ErrLinkDenied), true)
+
+	link := &AccountLink{Provider: LinkProviderDiscord, State: "abc"}
+	_, err = link.Complete(&Client{}, "other", "xyz")
+	assert.Equal(t, errors.Is(err, ErrLinkStateMismatch), true)
+}
+
+// newLinkedUserServer создаёт тестовый API, который на запрос списка пользователей возвращает body и запоминает
+// параметры запроса.
+func newLinkedUserServer(body string, query *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		*query = request.URL.RawQuery
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(body))
+	}))
+}
+
+func TestClient_FindUserByDiscordID(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected error
+	}{
+		{"linked", `{"items": [{"id": 7, "discord": {"id": "123"}}], "page": 1, "size": 1, "total": 1}`, nil},
+		{"no users", `{"items": [], "page": 1, "size": 1, "total": 0}`, ErrUserNotFound},
+		{"other account", `{"items": [{"id": 8, "discord": {"id": "456"}}], "page": 1, "size": 1, "total": 1}`, ErrUserNotFound},
+		{"not linked", `{"items": [{"id": 9, "discord": {"id": null}}], "page": 1, "size": 1, "total": 1}`, ErrUserNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var query string
+			server := newLinkedUserServer(test.body, &query)
+			defer server.Close()
+
+			user, err := (&Client{BaseURL: server.URL}).FindUserByDiscordID("123")
+			assert.Equal(t, errors.Is(err, test.expected), true)
+			assert.Equal(t, query, "discordId=123&page=1&size=1")
+			if test.expected == nil {
+				assert.Equal(t, user.ID, int64(7))
+			}
+		})
+	}
+
+	_, err := (&Client{}).FindUserByDiscordID("")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestClient_FindUserByVKID(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected error
+	}{
+		{"linked", `{"items": [{"id": 7, "vk": {"id": 123}}], "page": 1, "size": 1, "total": 1}`, nil},
+		{"no users", `{"items": [], "page": 1, "size": 1, "total": 0}`, ErrUserNotFound},
+		{"other account", `{"items": [{"id": 8, "vk": {"id": 456}}], "page": 1, "size": 1, "total": 1}`, ErrUserNotFound},
+		{"not linked", `{"items": [{"id": 9}], "page": 1, "size": 1, "total": 1}`, ErrUserNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var query string
+			server := newLinkedUserServer(test.body, &query)
+			defer server.Close()
+
+			user, err := (&Client{BaseURL: server.URL}).FindUserByVKID(123)
+			assert.Equal(t, errors.Is(err, test.expected), true)
+			assert.Equal(t, query, "page=1&size=1&vkId=123")
+			if test.expected == nil {
+				assert.Equal(t, user.ID, int64(7))
+			}
+		})
+	}
+
+	_, err := (&Client{}).FindUserByVKID(0)
+	assert.NotEqual(t, err, nil)
+}
@@ -0,0 +1,190 @@
+package superhub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultLogBodyLimit - максимальный размер тела запроса и ответа в журнале, если он не задан явно.
+const DefaultLogBodyLimit = 2048
+
+// redactedValue заменяет скрытые значения в журнале.
+const redactedValue = "[REDACTED]"
+
+// redactedHeaders - заголовки, значения которых никогда не попадают в журнал.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "X-Access-Token", "Cookie", "Set-Cookie"}
+
+// sensitiveFieldNames - части названий полей JSON и параметров запроса, значения которых скрываются. Сравнение
+// производится без учёта регистра, дефисов и подчёркиваний.
+var sensitiveFieldNames = []string{"token", "secret", "password", "passphrase", "authorization", "code"}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+\.)+[A-Za-z]{2,}`)
+
+func isSensitiveField(name string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	for _, sensitive := range sensitiveFieldNames {
+		if strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactEmails заменяет имя пользователя в адресах электронной почты, оставляя домен.
+func redactEmails(text string) string {
+	return emailPattern.ReplaceAllStringFunc(text, func(email string) string {
+		return "***" + email[strings.LastIndex(email, "@"):]
+	})
+}
+
+// redactHeaders возвращает копию заголовков, в которой скрыты учётные данные.
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, redactedValue)
+		}
+	}
+
+	return redacted
+}
+
+// redactURL возвращает адрес, в параметрах которого скрыты токены и адреса электронной почты.
+func redactURL(requestURL *url.URL) string {
+	redacted := *requestURL
+	redacted.User = nil
+
+	query := redacted.Query()
+	for name, values := range query {
+		for i := range values {
+			if isSensitiveField(name) {
+				values[i] = redactedValue
+			} else {
+				values[i] = redactEmails(values[i])
+			}
+		}
+	}
+	redacted.RawQuery = query.Encode()
+
+	return redactEmails(redacted.String())
+}
+
+// redactBody скрывает в теле запроса или ответа поля, похожие на токены, и адреса электронной почты, после чего
+// обрезает его до limit байт. Тело, которое не является JSON, обрабатывается как текст.
+func redactBody(body []byte, limit int) string {
+	text := string(body)
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if decoder.Decode(&value) == nil {
+		if encoded, err := json.Marshal(redactJSONValue(value)); err == nil {
+			text = string(encoded)
+		}
+	} else {
+		text = redactEmails(text)
+	}
+
+	if limit > 0 && len(text) > limit {
+		return fmt.Sprintf("%s... (%d bytes truncated)", strings.ToValidUTF8(text[:limit], ""), len(text)-limit)
+	}
+
+	return text
+}
+
+func redactJSONValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if isSensitiveField(key) {
+				value[key] = redactedValue
+			} else {
+				value[key] = redactJSONValue(field)
+			}
+		}
+		return value
+	case []any:
+		for i := range value {
+			value[i] = redactJSONValue(value[i])
+		}
+		return value
+	case string:
+		return redactEmails(value)
+	default:
+		return value
+	}
+}
+
+// GetLogBodyLimit возвращает максимальный размер тела запроса и ответа в журнале.
+func (c *Client) GetLogBodyLimit() int {
+	if c.LogBodyLimit == 0 {
+		return DefaultLogBodyLimit
+	}
+
+	return c.LogBodyLimit
+}
+
+// readLoggedResponseBody читает тело ответа для журнала и заменяет его копией, чтобы ответ можно было обработать.
+func readLoggedResponseBody(response *http.Response) []byte {
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	return body
+}
+
+// logExchange записывает запрос и ответ на него в журнал клиента (см. Client.Logger). Запросы, завершившиеся
+// ошибкой, записываются на уровне Warn, остальные - на уровне Debug.
+func (c *Client) logExchange(request *http.Request, response *http.Response, responseBody []byte, latency time.Duration, err error) {
+	level := slog.LevelDebug
+	if err != nil || response != nil && response.StatusCode >= http.StatusBadRequest {
+		level = slog.LevelWarn
+	}
+
+	ctx := request.Context()
+	if !c.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	limit := c.GetLogBodyLimit()
+	requestAttrs := []any{
+		slog.String("method", request.Method),
+		slog.String("url", redactURL(request.URL)),
+		slog.Any("headers", redactHeaders(request.Header)),
+	}
+	if limit > 0 && request.GetBody != nil {
+		if body, bodyErr := request.GetBody(); bodyErr == nil {
+			content, _ := io.ReadAll(body)
+			requestAttrs = append(requestAttrs, slog.String("body", redactBody(content, limit)))
+		}
+	}
+
+	attrs := []slog.Attr{slog.Group("request", requestAttrs...), slog.Duration("latency", latency)}
+	if response != nil {
+		responseAttrs := []any{
+			slog.Int("status", response.StatusCode),
+			slog.Any("headers", redactHeaders(response.Header)),
+		}
+		if limit > 0 {
+			responseAttrs = append(responseAttrs, slog.String("body", redactBody(responseBody, limit)))
+		}
+		attrs = append(attrs, slog.Group("response", responseAttrs...))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", redactEmails(err.Error())))
+	}
+
+	c.Logger.LogAttrs(ctx, level, "superhub request", attrs...)
+}
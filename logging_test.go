@@ -0,0 +1,44 @@
+package superhub
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+)
+
+func TestClient_LoggerRedactsSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = writer.Write([]byte(`{"error": "Bad Request", "message": "steve@example.com is taken", "refreshToken": "refresh-secret", "status": 400}`))
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	client := NewClientWithCredentials(NewPersistentToken(uuid.MustParse("0b9f6b52-5b4e-4cf4-9a58-0c9f3a1d4e21"), "access-secret"))
+	client.BaseURL = server.URL
+	client.Logger = slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client.LogBodyLimit = 64
+
+	_, err := client.Login(LoginForm{Email: "steve@example.com", Password: "hunter2", MfaCode: "123456"})
+	assert.NotEqual(t, err, nil)
+
+	_, err = client.ListUsers(UserListOptions{Email: "steve@example.com"})
+	assert.NotEqual(t, err, nil)
+
+	logged := output.String()
+	for _, secret := range []string{"access-secret", "refresh-secret", "hunter2", "123456", "steve@", "steve%40"} {
+		assert.Equal(t, strings.Contains(logged, secret), false)
+	}
+
+	assert.Equal(t, strings.Contains(logged, `"level":"WARN"`), true)
+	assert.Equal(t, strings.Contains(logged, `***@example.com`), true)
+	assert.Equal(t, strings.Contains(logged, `bytes truncated`), true)
+	assert.Equal(t, strings.Contains(logged, `"X-Access-Token":["[REDACTED]"]`), true)
+}
@@ -0,0 +1,153 @@
+package superhub
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Параметры одноразовых паролей TOTP (RFC 6238), которые использует API.
+const (
+	TotpDigits = 6
+	TotpPeriod = 30 * time.Second
+)
+
+// DefaultMfaIssuer - название сервиса, которое приложение-аутентификатор показывает рядом с одноразовым паролем.
+const DefaultMfaIssuer = "SuperHub"
+
+// ErrMfaRequired возвращается, если для выполнения запроса необходимо подтверждение одноразовым паролем.
+// API сообщает об этом ошибкой MFA_REQUIRED, её можно проверить с помощью errors.Is.
+var ErrMfaRequired = errors.New("multi-factor authentication required")
+
+// ErrInvalidMfaCode возвращается, если одноразовый пароль или код восстановления неверен или уже использован.
+var ErrInvalidMfaCode = errors.New("invalid multi-factor authentication code")
+
+// MfaEnrolment - начатое подключение двухфакторной аутентификации. Пользователь должен добавить секрет в приложение-
+// аутентификатор (например, отсканировав QR-код) и подтвердить подключение одноразовым паролем.
+type MfaEnrolment struct {
+	// Секрет в кодировке Base32 без выравнивания.
+	Secret string `json:"secret"`
+
+	// Имя аккаунта, под которым секрет отображается в приложении-аутентификаторе. Обычно - адрес электронной почты.
+	AccountName string `json:"accountName"`
+
+	// Название сервиса. Если не задано, используется DefaultMfaIssuer.
+	Issuer string `json:"issuer"`
+
+	// Дата, после которой подключение невозможно подтвердить.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// GetIssuer возвращает название сервиса для приложения-аутентификатора.
+func (e *MfaEnrolment) GetIssuer() string {
+	if e.Issuer == "" {
+		return DefaultMfaIssuer
+	}
+
+	return e.Issuer
+}
+
+// URI возвращает ссылку otpauth://, по которой приложение-аутентификатор добавляет секрет.
+func (e *MfaEnrolment) URI() string {
+	issuer := e.GetIssuer()
+	query := url.Values{}
+	query.Set("secret", e.Secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(TotpDigits))
+	query.Set("period", strconv.Itoa(int(TotpPeriod/time.Second)))
+
+	label := url.PathEscape(issuer + ":" + e.AccountName)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// WriteQRCode записывает QR-код со ссылкой URI в формате PNG. Каждый модуль QR-кода занимает scale x scale пикселей.
+func (e *MfaEnrolment) WriteQRCode(writer io.Writer, scale int) error {
+	return writeQRCodePNG(writer, e.URI(), scale)
+}
+
+// GenerateCode вычисляет одноразовый пароль для момента времени at. Позволяет, например, подтвердить подключение
+// двухфакторной аутентификации у служебного аккаунта без приложения-аутентификатора.
+func (e *MfaEnrolment) GenerateCode(at time.Time) (string, error) {
+	return GenerateTotpCode(e.Secret, at)
+}
+
+// GenerateTotpCode вычисляет одноразовый пароль TOTP (RFC 6238) по секрету в кодировке Base32 для момента времени at.
+func GenerateTotpCode(secret string, at time.Time) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("decoding secret: %s", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/int64(TotpPeriod/time.Second)))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0F
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7FFFFFFF
+
+	modulo := uint32(1)
+	for i := 0; i < TotpDigits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TotpDigits, value%modulo), nil
+}
+
+// MfaRecoveryCodes - коды восстановления, которые позволяют войти в аккаунт без приложения-аутентификатора.
+// Каждый код может быть использован только один раз.
+type MfaRecoveryCodes struct {
+	// Коды восстановления. API возвращает их только при создании, поэтому пользователь должен сохранить их сразу.
+	Codes []string `json:"codes"`
+
+	// Дата создания кодов. Ранее созданные коды при этом перестают действовать.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// mfaCodeForm - тело запроса с одноразовым паролем или кодом восстановления.
+type mfaCodeForm struct {
+	Code string `json:"code"`
+}
+
+// BeginMfaEnrolment начинает подключение двухфакторной аутентификации для текущего пользователя. Вернёт ошибку 409,
+// если двухфакторная аутентификация уже подключена.
+func (c *Client) BeginMfaEnrolment() (*MfaEnrolment, error) {
+	return InvokeEndpoint[MfaEnrolment](c, http.MethodPost, fmt.Sprintf("/users/%s/mfa/enrolment", c.getCurrentUserReference()), nil)
+}
+
+// ConfirmMfaEnrolment завершает подключение двухфакторной аутентификации одноразовым паролем из приложения-
+// аутентификатора и возвращает первые коды восстановления.
+func (c *Client) ConfirmMfaEnrolment(code string) (*MfaRecoveryCodes, error) {
+	return InvokeEndpoint[MfaRecoveryCodes](c, http.MethodPost, fmt.Sprintf("/users/%s/mfa/enrolment/confirmation", c.getCurrentUserReference()), mfaCodeForm{Code: code})
+}
+
+// VerifyMfaCode проверяет одноразовый пароль или код восстановления текущего пользователя. Вернёт ошибку,
+// для которой errors.Is(err, ErrInvalidMfaCode) = true, если код неверен.
+func (c *Client) VerifyMfaCode(code string) error {
+	return InvokeVoidEndpoint(c, http.MethodPost, fmt.Sprintf("/users/%s/mfa/verification", c.getCurrentUserReference()), mfaCodeForm{Code: code})
+}
+
+// DisableMfa отключает двухфакторную аутентификацию текущего пользователя. Для подтверждения необходим одноразовый
+// пароль или код восстановления.
+func (c *Client) DisableMfa(code string) error {
+	return InvokeVoidEndpoint(c, http.MethodDelete, fmt.Sprintf("/users/%s/mfa", c.getCurrentUserReference()), mfaCodeForm{Code: code})
+}
+
+// RegenerateMfaRecoveryCodes создаёт новые коды восстановления текущего пользователя взамен прежних. Для
+// подтверждения необходим одноразовый пароль.
+func (c *Client) RegenerateMfaRecoveryCodes(code string) (*MfaRecoveryCodes, error) {
+	return InvokeEndpoint[MfaRecoveryCodes](c, http.MethodPost, fmt.Sprintf("/users/%s/mfa/recovery-codes", c.getCurrentUserReference()), mfaCodeForm{Code: code})
+}
@@ -0,0 +1,175 @@
+package superhub
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestGenerateTotpCode(t *testing.T) {
+	// Тестовые значения из RFC 6238 для секрета "12345678901234567890".
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for unix, expected := range map[int64]string{59: "287082", 1111111109: "081804", 2000000000: "279037"} {
+		code, err := GenerateTotpCode(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, code, expected)
+	}
+}
+
+func TestMfaEnrolment_URI(t *testing.T) {
+	enrolment := &MfaEnrolment{Secret: "JBSWY3DPEHPK3PXP", AccountName: "steve@example.com"}
+	assert.Equal(t, enrolment.URI(), "otpauth://totp/SuperHub:steve@example.com?algorithm=SHA1&digits=6&issuer=SuperHub&period=30&secret=JBSWY3DPEHPK3PXP")
+
+	var buffer bytes.Buffer
+	err := enrolment.WriteQRCode(&buffer, 4)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	decoded, err := png.Decode(&buffer)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Ссылка длиной 115 байт помещается в QR-код версии 7 (45 x 45 модулей) с рамкой в 4 модуля с каждой стороны.
+	assert.Equal(t, len(enrolment.URI()), 115)
+	assert.Equal(t, decoded.Bounds().Dx(), (45+8)*4)
+}
+
+func TestEncodeQRCode(t *testing.T) {
+	// Длины данных подобраны так, чтобы проверить версии с одним и несколькими блоками, а также с информацией о версии.
+	for _, length := range []int{10, 67, 115, 200} {
+		testEncodeQRCode(t, bytes.Repeat([]byte("otpauth:/"), length)[:length])
+	}
+}
+
+func testEncodeQRCode(t *testing.T, data []byte) {
+	code, err := encodeQRCode(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Информация о формате: уровень коррекции M и маска, защищённые кодом БЧХ.
+	format := 0
+	for i, position := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		if code.modules[position[1]][position[0]] {
+			format |= 1 << i
+		}
+	}
+	format ^= 0x5412
+	assert.Equal(t, format>>13, 0)
+
+	mask := format >> 10
+	remainder := format
+	for i := 14; i >= 10; i-- {
+		if remainder>>i&1 == 1 {
+			remainder ^= 0x537 << (i - 10)
+		}
+	}
+	assert.Equal(t, remainder, 0)
+
+	// Обратное чтение кодовых слов: снятие маски и обход зигзагом.
+	var bits qrBitBuffer
+	for right := code.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+
+		for vertical := 0; vertical < code.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vertical
+				if (right+1)&2 == 0 {
+					y = code.size - 1 - vertical
+				}
+
+				if !code.function[y][x] {
+					bits = append(bits, code.modules[y][x] != qrMaskApplies(mask, x, y))
+				}
+			}
+		}
+	}
+
+	version := &qrVersions[(code.size-17)/4-1]
+	codewords := bits[:len(bits)/8*8].bytes()
+	blocks := len(version.blocks)
+
+	// Кодовые слова данных перемежаются по блокам, поэтому разбор повторяет порядок, в котором их размещает interleave.
+	words := make([][]byte, blocks)
+	next := 0
+	for i := 0; i < version.blocks[blocks-1]; i++ {
+		for block, size := range version.blocks {
+			if i < size {
+				words[block] = append(words[block], codewords[next])
+				next++
+			}
+		}
+	}
+	for i := 0; i < version.ecPerBlock; i++ {
+		for block := range words {
+			words[block] = append(words[block], codewords[next])
+			next++
+		}
+	}
+
+	// Каждый блок вместе с кодовыми словами коррекции должен делиться на порождающий многочлен: его значения
+	// в корнях многочлена равны нулю.
+	for _, word := range words {
+		root := byte(1)
+		for i := 0; i < version.ecPerBlock; i++ {
+			var value byte
+			for _, coefficient := range word {
+				value = gfMultiply(value, root) ^ coefficient
+			}
+			assert.Equal(t, value, byte(0))
+			root = gfMultiply(root, 0x02)
+		}
+	}
+
+	// Режим кодирования и длина данных в начале первого блока.
+	var header qrBitBuffer
+	for _, value := range words[0][:3] {
+		header.append(int(value), 8)
+	}
+
+	countBits := 8
+	if version == &qrVersions[9] {
+		countBits = 16
+	}
+
+	length := 0
+	for _, bit := range header[4 : 4+countBits] {
+		length <<= 1
+		if bit {
+			length |= 1
+		}
+	}
+
+	assert.Equal(t, words[0][0]>>4, byte(0b0100))
+	assert.Equal(t, length, len(data))
+}
+
+func TestErrorResponse_Is(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusUnauthorized)
+		_, _ = writer.Write([]byte(`{"error": "MFA_REQUIRED", "message": "code required", "status": 401}`))
+	}))
+	defer server.Close()
+
+	err := (&Client{BaseURL: server.URL}).DisableMfa("")
+	assert.Equal(t, errors.Is(err, ErrMfaRequired), true)
+	assert.Equal(t, errors.Is(err, ErrInvalidMfaCode), false)
+}
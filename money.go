@@ -0,0 +1,353 @@
+package superhub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// CurrencyRUB - код российского рубля. Все суммы, для которых API не возвращает валюту явно, указаны в рублях.
+const CurrencyRUB = "RUB"
+
+// DefaultCurrency - валюта, которая используется для сумм, пришедших от API без указания валюты.
+const DefaultCurrency = CurrencyRUB
+
+// MinorUnitsPerMajor - количество минимальных единиц (копеек, центов) в одной единице валюты.
+const MinorUnitsPerMajor = 100
+
+var (
+	// ErrCurrencyMismatch возвращается при попытке произвести операцию над суммами в разных валютах.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+
+	// ErrMoneyOutOfRange возвращается, если результат операции над суммой не помещается в int64 копеек.
+	ErrMoneyOutOfRange = errors.New("money amount is out of range")
+)
+
+// RoundingMode - правило округления, используемое при приведении суммы к минимальным единицам валюты.
+type RoundingMode int
+
+const (
+	// RoundHalfUp - округление к ближайшему, половина округляется от нуля. Используется по умолчанию.
+	RoundHalfUp RoundingMode = iota
+
+	// RoundHalfEven - банковское округление: к ближайшему, половина округляется к чётному.
+	RoundHalfEven
+
+	// RoundDown - отбрасывание дробной части (округление к нулю).
+	RoundDown
+
+	// RoundUp - округление от нуля.
+	RoundUp
+)
+
+// Money - денежная сумма с фиксированной точностью. Хранится в минимальных единицах валюты, поэтому сложение и
+// вычитание сумм не приводит к потере копеек, в отличие от float64.
+//
+// В JSON сумма представляется числом в основных единицах валюты (например, 123.45), как и до появления Money,
+// поэтому формат запросов и ответов API не изменился. Валюта в JSON не передаётся: при десериализации она берётся
+// из структуры, содержащей сумму (см. PaymentAmount), или принимается равной DefaultCurrency.
+type Money struct {
+	// Сумма в минимальных единицах валюты (копейках).
+	Minor int64
+
+	// Код валюты. Пустое значение допускается только у нулевой суммы и совместимо с любой валютой.
+	Currency string
+}
+
+// NewMoney создаёт сумму из количества минимальных единиц валюты.
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// MoneyFromFloat создаёт сумму из значения в основных единицах валюты с округлением до копеек по правилу
+// RoundHalfUp. Округляется кратчайшее десятичное представление числа, поэтому, например, 1.005 превратится в 1.01.
+// Используется для перехода со старых полей типа float64. Вернёт ошибку, если value не является конечным числом
+// или сумма не помещается в int64 копеек.
+func MoneyFromFloat(value float64, currency string) (Money, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Money{}, fmt.Errorf("invalid money amount: %v", value)
+	}
+
+	return ParseMoney(strconv.FormatFloat(value, 'f', -1, 64), currency)
+}
+
+// ParseMoney разбирает десятичную запись суммы в основных единицах валюты (например, "123.45" или "-1e2").
+// Лишние знаки после запятой округляются по правилу RoundHalfUp.
+func ParseMoney(value string, currency string) (Money, error) {
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid money amount: %q", value)
+	}
+
+	rat.Mul(rat, big.NewRat(MinorUnitsPerMajor, 1))
+	minor, err := roundRat(rat, RoundHalfUp)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// Float64 возвращает сумму в основных единицах валюты в виде числа с плавающей точкой. Оставлен для совместимости с
+// кодом, который использовал поля типа float64, и не должен использоваться для дальнейших вычислений.
+func (m Money) Float64() float64 {
+	value, _ := new(big.Rat).SetFrac64(m.Minor, MinorUnitsPerMajor).Float64()
+	return value
+}
+
+// IsZero возвращает true, если сумма равна нулю.
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// IsNegative возвращает true, если сумма меньше нуля.
+func (m Money) IsNegative() bool {
+	return m.Minor < 0
+}
+
+// Sign возвращает -1, 0 или 1 в зависимости от знака суммы.
+func (m Money) Sign() int {
+	switch {
+	case m.Minor < 0:
+		return -1
+	case m.Minor > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Neg возвращает сумму с противоположным знаком.
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Abs возвращает абсолютное значение суммы.
+func (m Money) Abs() Money {
+	if m.Minor < 0 {
+		return m.Neg()
+	}
+
+	return m
+}
+
+// Add возвращает сумму m и other. Вернёт ErrCurrencyMismatch, если суммы указаны в разных валютах,
+// и ErrMoneyOutOfRange, если результат не помещается в int64 копеек.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.commonCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+
+	minor := m.Minor + other.Minor
+	if other.Minor > 0 && minor < m.Minor || other.Minor < 0 && minor > m.Minor {
+		return Money{}, ErrMoneyOutOfRange
+	}
+
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// Sub возвращает разность m и other. Вернёт ErrCurrencyMismatch, если суммы указаны в разных валютах,
+// и ErrMoneyOutOfRange, если результат не помещается в int64 копеек.
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.commonCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+
+	minor := m.Minor - other.Minor
+	if other.Minor < 0 && minor < m.Minor || other.Minor > 0 && minor > m.Minor {
+		return Money{}, ErrMoneyOutOfRange
+	}
+
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// Mul умножает сумму на factor и округляет результат до копеек по правилу mode. Вернёт ошибку, если factor
+// не является конечным числом или результат не помещается в int64.
+func (m Money) Mul(factor float64, mode RoundingMode) (Money, error) {
+	if math.IsNaN(factor) || math.IsInf(factor, 0) {
+		return Money{}, fmt.Errorf("invalid money factor: %v", factor)
+	}
+
+	rat, _ := new(big.Rat).SetString(strconv.FormatFloat(factor, 'f', -1, 64))
+	rat.Mul(rat, new(big.Rat).SetInt64(m.Minor))
+
+	minor, err := roundRat(rat, mode)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Minor: minor, Currency: m.Currency}, nil
+}
+
+// Round округляет сумму до precision знаков после запятой по правилу mode. Например, precision = 0 округляет сумму
+// до целых рублей.
+func (m Money) Round(precision int, mode RoundingMode) Money {
+	unit := int64(MinorUnitsPerMajor)
+	for i := 0; i < precision && unit > 1; i++ {
+		unit /= 10
+	}
+
+	minor, _ := roundRat(big.NewRat(m.Minor, unit), mode)
+	return Money{Minor: minor * unit, Currency: m.Currency}
+}
+
+// Compare сравнивает суммы и возвращает -1, 0 или 1, если m соответственно меньше, равна или больше other.
+// Вернёт ErrCurrencyMismatch, если суммы указаны в разных валютах.
+func (m Money) Compare(other Money) (int, error) {
+	_, err := m.commonCurrency(other)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.Minor < other.Minor:
+		return -1, nil
+	case m.Minor > other.Minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Decimal возвращает десятичную запись суммы в основных единицах валюты, всегда с двумя знаками после запятой.
+// Например, "-1234.50".
+func (m Money) Decimal() string {
+	sign := ""
+	minor := m.Minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, minor/MinorUnitsPerMajor, minor%MinorUnitsPerMajor)
+}
+
+// String возвращает сумму вместе с кодом валюты. Например, "1234.50 RUB".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+
+	return fmt.Sprintf("%s %s", m.Decimal(), m.Currency)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	// Незначащие нули отбрасываются, чтобы сумма выглядела так же, как выглядело число float64.
+	value := strings.TrimSuffix(strings.TrimRight(m.Decimal(), "0"), ".")
+	return []byte(value), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	// Некоторые конечные точки могут возвращать сумму строкой.
+	data = bytes.Trim(data, `"`)
+
+	var number json.Number
+	err := json.Unmarshal(data, &number)
+	if err != nil {
+		return fmt.Errorf("parsing money amount: %s", err)
+	}
+
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	parsed, err := ParseMoney(number.String(), currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+func (m Money) commonCurrency(other Money) (string, error) {
+	switch {
+	case m.Currency == other.Currency || other.Currency == "":
+		return m.Currency, nil
+	case m.Currency == "":
+		return other.Currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+}
+
+// NullMoney - сумма, которая может отсутствовать. Аналог null.Float для денежных сумм.
+type NullMoney struct {
+	Money
+
+	// Имеет значение true, если сумма задана.
+	Valid bool
+}
+
+// NewNullMoney создаёт заданную сумму, которая может отсутствовать.
+func NewNullMoney(money Money) NullMoney {
+	return NullMoney{Money: money, Valid: true}
+}
+
+func (m NullMoney) MarshalJSON() ([]byte, error) {
+	if !m.Valid {
+		return []byte("null"), nil
+	}
+
+	return m.Money.MarshalJSON()
+}
+
+func (m *NullMoney) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*m = NullMoney{}
+		return nil
+	}
+
+	err := m.Money.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+
+	m.Valid = true
+	return nil
+}
+
+func roundRat(rat *big.Rat, mode RoundingMode) (int64, error) {
+	quotient, remainder := new(big.Int).QuoRem(rat.Num(), rat.Denom(), new(big.Int))
+
+	if remainder.Sign() != 0 {
+		// Сравнение удвоенного остатка со знаменателем показывает, больше ли дробная часть половины.
+		half := new(big.Int).Abs(remainder)
+		half.Lsh(half, 1)
+		comparison := half.Cmp(rat.Denom())
+
+		awayFromZero := false
+		switch mode {
+		case RoundHalfUp:
+			awayFromZero = comparison >= 0
+		case RoundHalfEven:
+			awayFromZero = comparison > 0 || comparison == 0 && quotient.Bit(0) == 1
+		case RoundUp:
+			awayFromZero = true
+		case RoundDown:
+			awayFromZero = false
+		}
+
+		if awayFromZero {
+			quotient.Add(quotient, big.NewInt(int64(rat.Sign())))
+		}
+	}
+
+	if !quotient.IsInt64() {
+		return 0, ErrMoneyOutOfRange
+	}
+
+	return quotient.Int64(), nil
+}
@@ -0,0 +1,171 @@
+package superhub
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestMoney_JSON(t *testing.T) {
+	values := map[string]Money{
+		`123.45`:  NewMoney(12345, CurrencyRUB),
+		`100`:     NewMoney(10000, CurrencyRUB),
+		`0.5`:     NewMoney(50, CurrencyRUB),
+		`-0.07`:   NewMoney(-7, CurrencyRUB),
+		`0`:       NewMoney(0, CurrencyRUB),
+		`1.005`:   NewMoney(101, CurrencyRUB),
+		`"12.30"`: NewMoney(1230, CurrencyRUB),
+	}
+
+	for data, expected := range values {
+		var money Money
+		err := json.Unmarshal([]byte(data), &money)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, money, expected)
+	}
+
+	for _, value := range []string{`123.45`, `100`, `0.5`, `-0.07`, `0`} {
+		marshalled, err := json.Marshal(values[value])
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, string(marshalled), value)
+	}
+}
+
+func TestPaymentAmount_UnmarshalJSON(t *testing.T) {
+	var amount PaymentAmount
+	err := json.Unmarshal([]byte(`{"sum": 10.1, "currency": "USD"}`), &amount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, amount.Sum, NewMoney(1010, "USD"))
+}
+
+func TestServerCost_UnmarshalJSON(t *testing.T) {
+	var cost ServerCost
+	err := json.Unmarshal([]byte(`{"base": 0.1, "freeze": null}`), &cost)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, cost.Base, NewMoney(10, CurrencyRUB))
+	assert.Equal(t, cost.Freeze.Valid, false)
+
+	err = json.Unmarshal([]byte(`{"base": 0.1, "freeze": 0.2}`), &cost)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, cost.Freeze, NewNullMoney(NewMoney(20, CurrencyRUB)))
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	total := Money{}
+	for i := 0; i < 10; i++ {
+		var err error
+		total, err = total.Add(NewMoney(10, CurrencyRUB))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	assert.Equal(t, total, NewMoney(100, CurrencyRUB))
+
+	_, err := total.Add(NewMoney(1, "USD"))
+	assert.Equal(t, errors.Is(err, ErrCurrencyMismatch), true)
+
+	comparison, err := total.Compare(NewMoney(99, CurrencyRUB))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, comparison, 1)
+}
+
+func TestMoney_Rounding(t *testing.T) {
+	money := NewMoney(1000, CurrencyRUB)
+	products := []struct {
+		factor   float64
+		mode     RoundingMode
+		expected Money
+	}{
+		{0.0025, RoundHalfUp, NewMoney(3, CurrencyRUB)},
+		{0.0025, RoundHalfEven, NewMoney(2, CurrencyRUB)},
+		{0.0025, RoundDown, NewMoney(2, CurrencyRUB)},
+		{-0.0021, RoundUp, NewMoney(-3, CurrencyRUB)},
+	}
+
+	for _, product := range products {
+		result, err := money.Mul(product.factor, product.mode)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, result, product.expected)
+	}
+
+	assert.Equal(t, NewMoney(12350, CurrencyRUB).Round(0, RoundHalfUp), NewMoney(12400, CurrencyRUB))
+	assert.Equal(t, NewMoney(-12350, CurrencyRUB).Round(0, RoundHalfUp), NewMoney(-12400, CurrencyRUB))
+	assert.Equal(t, NewMoney(12345, CurrencyRUB).Round(1, RoundDown), NewMoney(12340, CurrencyRUB))
+}
+
+func TestMoney_MulInvalidFactor(t *testing.T) {
+	money := NewMoney(1000, CurrencyRUB)
+	for _, factor := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e30} {
+		_, err := money.Mul(factor, RoundHalfUp)
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, NewMoney(-123450, CurrencyRUB).String(), "-1234.50 RUB")
+	assert.Equal(t, NewMoney(5, "").String(), "0.05")
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	money, err := MoneyFromFloat(0.1+0.2, CurrencyRUB)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, money, NewMoney(30, CurrencyRUB))
+	assert.Equal(t, money.Float64(), 0.3)
+
+	for _, value := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e30} {
+		_, err = MoneyFromFloat(value, CurrencyRUB)
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestMoney_ArithmeticOverflow(t *testing.T) {
+	maximum := NewMoney(math.MaxInt64, CurrencyRUB)
+	minimum := NewMoney(math.MinInt64, CurrencyRUB)
+	one := NewMoney(1, CurrencyRUB)
+
+	_, err := maximum.Add(one)
+	assert.Equal(t, errors.Is(err, ErrMoneyOutOfRange), true)
+
+	_, err = minimum.Sub(one)
+	assert.Equal(t, errors.Is(err, ErrMoneyOutOfRange), true)
+
+	_, err = NewMoney(0, CurrencyRUB).Sub(minimum)
+	assert.Equal(t, errors.Is(err, ErrMoneyOutOfRange), true)
+
+	sum, err := maximum.Add(one.Neg())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sum.Minor, int64(math.MaxInt64-1))
+
+	comparison, err := minimum.Compare(maximum)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, comparison, -1)
+}
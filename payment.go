@@ -1,6 +1,7 @@
 package superhub
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,12 +12,30 @@ import (
 // PaymentAmount - размер платежа. Описывает сумму и валюту, в которой проводится платёж.
 type PaymentAmount struct {
 	// Сумма платежа в валюте, соответствующей значению Currency.
-	Sum float64 `json:"sum"`
+	Sum Money `json:"sum"`
 
 	// Валюта платежа.
 	Currency string `json:"currency"`
 }
 
+func (a *PaymentAmount) UnmarshalJSON(data []byte) error {
+	// Псевдоним типа нужен, чтобы избежать рекурсивного вызова UnmarshalJSON.
+	type plainPaymentAmount PaymentAmount
+
+	var plain plainPaymentAmount
+	err := json.Unmarshal(data, &plain)
+	if err != nil {
+		return err
+	}
+
+	if plain.Currency != "" {
+		plain.Sum.Currency = plain.Currency
+	}
+
+	*a = PaymentAmount(plain)
+	return nil
+}
+
 // PaymentSourceType - тип "источника" платежа - действия, вызвавшего создание данного платежа.
 type PaymentSourceType string
 
@@ -33,6 +52,18 @@ const (
 	// PaymentSourceReferralWelcomeBonus - приветственный бонус для пользователей, зарегистрированных по приглашению.
 	PaymentSourceReferralWelcomeBonus PaymentSourceType = "REFERRAL_WELCOME_BONUS"
 
+	// PaymentSourceRefund - возврат средств по другому платежу. Идентификатор источника содержит идентификатор
+	// платежа, по которому проведён возврат.
+	PaymentSourceRefund PaymentSourceType = "REFUND"
+
+	// PaymentSourceTransfer - перевод средств между пользователями. Идентификатор источника содержит идентификатор
+	// второго участника перевода: получателя для списания и отправителя для зачисления.
+	PaymentSourceTransfer PaymentSourceType = "TRANSFER"
+
+	// PaymentSourceAdjustment - корректировка баланса администрацией хостинга. Идентификатор источника содержит
+	// код причины корректировки (см. AdjustmentReason).
+	PaymentSourceAdjustment PaymentSourceType = "ADJUSTMENT"
+
 	// PaymentSourceOther используется как стандартное значение для типа источника платежа.
 	PaymentSourceOther PaymentSourceType = "OTHER"
 )
@@ -45,7 +76,7 @@ type PaymentSource struct {
 
 	// Идентификатор источника. Для некоторых типов всегда имеет пустое значение ("TOP_UP", "OTHER"),
 	// для других - всегда непустое. Например, для типа "REFERRAL" будет содержать значение пользователя, от которого
-	// получен бонус по реферальной системе.
+	// получен бонус по реферальной системе, а для типа "REFUND" - идентификатор платежа, по которому проведён возврат.
 	ID null.String `json:"id,omitempty"`
 }
 
@@ -60,6 +91,26 @@ const (
 	PaymentModeTest = "TEST"
 )
 
+// PaymentStatus отражает стадию обработки платежа.
+type PaymentStatus string
+
+const (
+	// PaymentStatusPending - платёж ожидает обработки, например оплаты пользователем.
+	PaymentStatusPending PaymentStatus = "PENDING"
+
+	// PaymentStatusCompleted - платёж завершён, баланс пользователя изменён.
+	PaymentStatusCompleted PaymentStatus = "COMPLETED"
+
+	// PaymentStatusFailed - платёжная система отклонила оплату.
+	PaymentStatusFailed PaymentStatus = "FAILED"
+
+	// PaymentStatusCancelled - платёж отменён пользователем, администрацией или платёжной системой.
+	PaymentStatusCancelled PaymentStatus = "CANCELLED"
+
+	// PaymentStatusExpired - пользователь не произвёл оплату за отведённое время.
+	PaymentStatusExpired PaymentStatus = "EXPIRED"
+)
+
 // Payment описывает платёж - сущность, используемую для хранения истории изменения баланса пользователя на хостинге.
 // Платежи могут иметь как положительную, так и отрицательную сумму. Платежи с положительной суммой отражают пополнения
 // баланса, будь то пополнение пользователем или администрацией хостинга. Платежи с отрицательной суммой отражают
@@ -91,6 +142,10 @@ type Payment struct {
 	// как пользователь производит оплату, сумма платежа зачисляется на баланс, а Completed изменяется на true.
 	Completed bool `json:"completed"`
 
+	// Стадия обработки платежа. Платёж со стадией PaymentStatusFailed, PaymentStatusCancelled или
+	// PaymentStatusExpired уже никогда не будет завершён.
+	Status PaymentStatus `json:"status,omitempty"`
+
 	// Дата создания платежа.
 	CreatedAt time.Time `json:"createdAt"`
 
@@ -98,11 +153,31 @@ type Payment struct {
 	UpdatedAt null.Time `json:"updatedAt"`
 }
 
+// IsAbandoned возвращает true, если платёж не завершён и уже никогда не будет завершён: оплата отклонена,
+// платёж отменён или срок оплаты истёк.
+func (p *Payment) IsAbandoned() bool {
+	if p.Completed {
+		return false
+	}
+
+	switch p.Status {
+	case PaymentStatusFailed, PaymentStatusCancelled, PaymentStatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
 // GetPayments получает список всех платежей в системе.
 func (c *Client) GetPayments() (*[]Payment, error) {
 	return InvokeEndpoint[[]Payment](c, http.MethodGet, "/payments", nil)
 }
 
+// GetPayment получает платёж с заданным идентификатором.
+func (c *Client) GetPayment(id string) (*Payment, error) {
+	return InvokeEndpoint[Payment](c, http.MethodGet, fmt.Sprintf("/payments/%s", id), nil)
+}
+
 // GetUserPayments получает список платежей пользователя.
 func (c *Client) GetUserPayments(userID int64) (*[]Payment, error) {
 	return InvokeEndpoint[[]Payment](c, http.MethodGet, fmt.Sprintf("/users/%d/payments", userID), nil)
@@ -110,13 +185,21 @@ func (c *Client) GetUserPayments(userID int64) (*[]Payment, error) {
 
 type PaymentCreationForm struct {
 	// Сумма платежа в рублях.
-	Amount float64 `json:"amount"`
+	Amount Money `json:"amount"`
 
 	// Описание платежа.
 	Description null.String `json:"description"`
 
 	// Источник платежа.
 	Source *PaymentSource `json:"source"`
+
+	// Ключ идемпотентности (см. IdempotencyKeyHeader). Чтобы платёж не был создан дважды, при повторной отправке
+	// формы после ошибки необходимо использовать тот же ключ. Если не задан, для каждого вызова создаётся новый ключ.
+	IdempotencyKey string `json:"-"`
+}
+
+func (f PaymentCreationForm) GetIdempotencyKey() string {
+	return f.IdempotencyKey
 }
 
 // CreatePayment создаёт платёж для данного пользователя.
@@ -0,0 +1,384 @@
+package superhub
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Размеры страницы A4 в пунктах.
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+)
+
+// pdfFontFace - файл шрифта TrueType, который встраивается в документы. Используются шрифты Go: они содержат
+// кириллические глифы и распространяются под лицензией BSD, которая допускает встраивание.
+type pdfFontFace struct {
+	name string
+	data []byte
+
+	once       sync.Once
+	font       *sfnt.Font
+	compressed []byte
+	err        error
+}
+
+var (
+	pdfRegularFace = &pdfFontFace{name: "GoRegular", data: goregular.TTF}
+	pdfBoldFace    = &pdfFontFace{name: "GoBold", data: gobold.TTF}
+)
+
+// load разбирает файл шрифта и сжимает его для встраивания. Выполняется один раз, результат используется всеми
+// документами.
+func (f *pdfFontFace) load() (*sfnt.Font, error) {
+	f.once.Do(func() {
+		f.font, f.err = sfnt.Parse(f.data)
+		if f.err != nil {
+			f.err = fmt.Errorf("parsing font %s: %s", f.name, f.err)
+			return
+		}
+
+		var buffer bytes.Buffer
+		writer := zlib.NewWriter(&buffer)
+		_, _ = writer.Write(f.data)
+		_ = writer.Close()
+		f.compressed = buffer.Bytes()
+	})
+
+	return f.font, f.err
+}
+
+// pdfFont - шрифт в составе документа. Текст выводится номерами глифов (кодировка Identity-H), поэтому шрифт
+// запоминает все использованные глифы, чтобы записать их ширину и соответствующие им символы Unicode.
+type pdfFont struct {
+	face       *pdfFontFace
+	font       *sfnt.Font
+	buffer     sfnt.Buffer
+	unitsPerEm fixed.Int26_6
+
+	glyphs map[rune]sfnt.GlyphIndex
+	widths map[sfnt.GlyphIndex]int
+	chars  map[sfnt.GlyphIndex]rune
+}
+
+func newPDFFont(face *pdfFontFace) (*pdfFont, error) {
+	font, err := face.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pdfFont{
+		face:       face,
+		font:       font,
+		unitsPerEm: fixed.Int26_6(font.UnitsPerEm()) << 6,
+		glyphs:     make(map[rune]sfnt.GlyphIndex),
+		widths:     make(map[sfnt.GlyphIndex]int),
+		chars:      make(map[sfnt.GlyphIndex]rune),
+	}, nil
+}
+
+// glyph возвращает номер глифа символа char. Символы, которых нет в шрифте, заменяются на "?".
+func (f *pdfFont) glyph(char rune) sfnt.GlyphIndex {
+	if glyph, ok := f.glyphs[char]; ok {
+		return glyph
+	}
+
+	glyph, err := f.font.GlyphIndex(&f.buffer, char)
+	if err != nil || glyph == 0 {
+		if char == '?' {
+			return 0
+		}
+
+		glyph = f.glyph('?')
+		f.glyphs[char] = glyph
+		return glyph
+	}
+
+	advance, err := f.font.GlyphAdvance(&f.buffer, glyph, f.unitsPerEm, font.HintingNone)
+	if err == nil {
+		f.widths[glyph] = f.scale(advance)
+	}
+
+	f.glyphs[char] = glyph
+	f.chars[glyph] = char
+	return glyph
+}
+
+// scale переводит значение из единиц шрифта в тысячные доли кегля.
+func (f *pdfFont) scale(value fixed.Int26_6) int {
+	return int(int64(value) * 1000 / int64(f.unitsPerEm))
+}
+
+// textWidth возвращает ширину текста в пунктах.
+func (f *pdfFont) textWidth(text string, size float64) float64 {
+	total := 0
+	for _, char := range text {
+		total += f.widths[f.glyph(char)]
+	}
+
+	return float64(total) * size / 1000
+}
+
+// encode возвращает текст в виде шестнадцатеричной строки PDF из номеров глифов.
+func (f *pdfFont) encode(text string) string {
+	var builder strings.Builder
+	builder.WriteByte('<')
+	for _, char := range text {
+		fmt.Fprintf(&builder, "%04X", uint16(f.glyph(char)))
+	}
+	builder.WriteByte('>')
+	return builder.String()
+}
+
+// usedGlyphs возвращает использованные в документе глифы в порядке возрастания номеров.
+func (f *pdfFont) usedGlyphs() []sfnt.GlyphIndex {
+	glyphs := make([]sfnt.GlyphIndex, 0, len(f.chars))
+	for glyph := range f.chars {
+		glyphs = append(glyphs, glyph)
+	}
+
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
+
+// widthArray возвращает массив W шрифта CIDFontType2 с шириной использованных глифов.
+func (f *pdfFont) widthArray() string {
+	var builder strings.Builder
+	builder.WriteByte('[')
+	for _, glyph := range f.usedGlyphs() {
+		fmt.Fprintf(&builder, " %d [%d]", glyph, f.widths[glyph])
+	}
+	builder.WriteString(" ]")
+	return builder.String()
+}
+
+// toUnicode возвращает таблицу CMap, по которой программы просмотра восстанавливают текст из номеров глифов
+// при копировании и поиске.
+func (f *pdfFont) toUnicode() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	// В одном блоке bfchar допускается не больше 100 соответствий.
+	glyphs := f.usedGlyphs()
+	for start := 0; start < len(glyphs); start += 100 {
+		end := start + 100
+		if end > len(glyphs) {
+			end = len(glyphs)
+		}
+
+		fmt.Fprintf(&buffer, "%d beginbfchar\n", end-start)
+		for _, glyph := range glyphs[start:end] {
+			fmt.Fprintf(&buffer, "<%04X> <", uint16(glyph))
+			for _, unit := range utf16.Encode([]rune{f.chars[glyph]}) {
+				fmt.Fprintf(&buffer, "%04X", unit)
+			}
+			buffer.WriteString(">\n")
+		}
+		buffer.WriteString("endbfchar\n")
+	}
+
+	buffer.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return buffer.Bytes()
+}
+
+// descriptor возвращает словарь FontDescriptor без ссылки на файл шрифта.
+func (f *pdfFont) descriptor() string {
+	metrics, _ := f.font.Metrics(&f.buffer, f.unitsPerEm, font.HintingNone)
+	bounds, _ := f.font.Bounds(&f.buffer, f.unitsPerEm, font.HintingNone)
+
+	// Ось Y в sfnt направлена вниз, а в PDF - вверх.
+	return fmt.Sprintf("/Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 "+
+		"/Ascent %d /Descent %d /CapHeight %d /StemV 80",
+		f.face.name, f.scale(bounds.Min.X), -f.scale(bounds.Max.Y), f.scale(bounds.Max.X), -f.scale(bounds.Min.Y),
+		f.scale(metrics.Ascent), -f.scale(metrics.Descent), f.scale(metrics.CapHeight))
+}
+
+// wrapText разбивает текст на строки, ширина которых не превышает width.
+func (f *pdfFont) wrapText(text string, size float64, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+
+			if line != "" && f.textWidth(candidate, size) > width {
+				lines = append(lines, line)
+				candidate = word
+			}
+
+			line = candidate
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// pdfPage - страница документа. Координаты отсчитываются от левого нижнего угла страницы.
+type pdfPage struct {
+	document *pdfDocument
+	content  bytes.Buffer
+}
+
+func (p *pdfPage) text(x, y, size float64, bold bool, text string) {
+	name := "F1"
+	if bold {
+		name = "F2"
+	}
+
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td %s Tj ET\n", name, size, x, y, p.document.font(bold).encode(text))
+}
+
+func (p *pdfPage) textRight(right, y, size float64, bold bool, text string) {
+	p.text(right-p.document.font(bold).textWidth(text, size), y, size, bold, text)
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2)
+}
+
+// pdfDocument - минимальная реализация PDF 1.4, достаточная для вывода текста и линий. Шрифты встраиваются
+// в документ (см. pdfFontFace), поэтому текст на любом языке, поддерживаемом шрифтом, отображается одинаково
+// во всех программах просмотра.
+type pdfDocument struct {
+	pages   []*pdfPage
+	regular *pdfFont
+	bold    *pdfFont
+}
+
+func newPDFDocument() (*pdfDocument, error) {
+	regular, err := newPDFFont(pdfRegularFace)
+	if err != nil {
+		return nil, err
+	}
+
+	bold, err := newPDFFont(pdfBoldFace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pdfDocument{regular: regular, bold: bold}, nil
+}
+
+func (d *pdfDocument) font(bold bool) *pdfFont {
+	if bold {
+		return d.bold
+	}
+
+	return d.regular
+}
+
+func (d *pdfDocument) addPage() *pdfPage {
+	page := &pdfPage{document: d}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// pdfFontObjects - количество объектов, которые занимает в документе один шрифт.
+const pdfFontObjects = 5
+
+func (d *pdfDocument) writeTo(writer io.Writer) error {
+	var buffer bytes.Buffer
+	var offsets []int
+
+	beginObject := func() int {
+		offsets = append(offsets, buffer.Len())
+		number := len(offsets)
+		fmt.Fprintf(&buffer, "%d 0 obj\n", number)
+		return number
+	}
+	endObject := func() {
+		buffer.WriteString("endobj\n")
+	}
+	writeStream := func(dictionary string, data []byte) {
+		fmt.Fprintf(&buffer, "<< /Length %d%s >>\nstream\n", len(data), dictionary)
+		buffer.Write(data)
+		buffer.WriteString("\nendstream\n")
+	}
+
+	// Второй строкой идёт комментарий с байтами вне ASCII, чтобы файл распознавался как двоичный.
+	buffer.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Номера объектов: 1 - каталог, 2 - дерево страниц, далее по pdfFontObjects объектов на каждый шрифт и по два
+	// объекта (страница и её содержимое) на каждую страницу.
+	fonts := []*pdfFont{d.regular, d.bold}
+	firstPage := 3 + len(fonts)*pdfFontObjects
+
+	beginObject()
+	buffer.WriteString("<< /Type /Catalog /Pages 2 0 R >>\n")
+	endObject()
+
+	beginObject()
+	buffer.WriteString("<< /Type /Pages /Kids [")
+	for i := range d.pages {
+		fmt.Fprintf(&buffer, " %d 0 R", firstPage+i*2)
+	}
+	fmt.Fprintf(&buffer, " ] /Count %d >>\n", len(d.pages))
+	endObject()
+
+	for _, font := range fonts {
+		number := beginObject()
+		fmt.Fprintf(&buffer, "<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+			"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>\n", font.face.name, number+1, number+4)
+		endObject()
+
+		beginObject()
+		fmt.Fprintf(&buffer, "<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W %s >>\n", font.face.name, number+2, font.widthArray())
+		endObject()
+
+		beginObject()
+		fmt.Fprintf(&buffer, "<< %s /FontFile2 %d 0 R >>\n", font.descriptor(), number+3)
+		endObject()
+
+		beginObject()
+		writeStream(fmt.Sprintf(" /Length1 %d /Filter /FlateDecode", len(font.face.data)), font.face.compressed)
+		endObject()
+
+		beginObject()
+		writeStream("", font.toUnicode())
+		endObject()
+	}
+
+	for _, page := range d.pages {
+		number := beginObject()
+		fmt.Fprintf(&buffer, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] ", pdfPageWidth, pdfPageHeight)
+		fmt.Fprintf(&buffer, "/Resources << /Font << /F1 3 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>\n",
+			3+pdfFontObjects, number+1)
+		endObject()
+
+		beginObject()
+		writeStream("", page.content.Bytes())
+		endObject()
+	}
+
+	xref := buffer.Len()
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := buffer.WriteTo(writer)
+	return err
+}
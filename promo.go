@@ -0,0 +1,221 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// DiscountType - способ расчёта скидки.
+type DiscountType string
+
+const (
+	// DiscountPercentage - скидка в процентах от стоимости.
+	DiscountPercentage DiscountType = "PERCENTAGE"
+
+	// DiscountFixed - скидка на фиксированную сумму.
+	DiscountFixed DiscountType = "FIXED"
+)
+
+// Discount - скидка на услугу.
+type Discount struct {
+	// Способ расчёта скидки.
+	Type DiscountType `json:"type"`
+
+	// Размер скидки в процентах от 0 до 100. Имеет значение только для DiscountPercentage.
+	Percent float64 `json:"percent,omitempty"`
+
+	// Размер скидки в рублях. Имеет значение только для DiscountFixed.
+	Amount NullMoney `json:"amount"`
+
+	// Промокод, по которому получена скидка. Пустой, если скидка предоставлена без промокода.
+	PromoCode null.String `json:"promoCode"`
+}
+
+// Apply возвращает стоимость cost с учётом скидки. Скидка в процентах округляется до копеек по правилу RoundHalfUp,
+// а её размер ограничивается диапазоном от 0 до 100. Стоимость с учётом скидки не может быть отрицательной.
+// Возвращает ошибку, если размер скидки в процентах не является конечным числом или если валюта фиксированной
+// скидки не совпадает с валютой стоимости.
+func (d *Discount) Apply(cost Money) (Money, error) {
+	var discounted Money
+	var err error
+	switch d.Type {
+	case DiscountPercentage:
+		percent := math.Min(math.Max(d.Percent, 0), 100)
+		discounted, err = cost.Mul(1-percent/100, RoundHalfUp)
+	case DiscountFixed:
+		discounted, err = cost.Sub(d.Amount.Money)
+	default:
+		return cost, nil
+	}
+	if err != nil {
+		return Money{}, fmt.Errorf("applying %s discount: %w", d.Type, err)
+	}
+
+	if discounted.IsNegative() {
+		return NewMoney(0, cost.Currency), nil
+	}
+
+	return discounted, nil
+}
+
+// PromoCode - промокод, дающий скидку на услуги хостинга.
+type PromoCode struct {
+	// Промокод. Является его идентификатором.
+	Code string `json:"code"`
+
+	// Скидка, которую даёт промокод.
+	Discount Discount `json:"discount"`
+
+	// Идентификаторы тарифов, на которые действует промокод. Если пуст, промокод действует на любые серверы.
+	TariffIDs []string `json:"tariffIds"`
+
+	// Максимальное количество активаций промокода. Если не задано, количество не ограничено.
+	MaxUses null.Int `json:"maxUses"`
+
+	// Текущее количество активаций промокода.
+	Uses int64 `json:"uses"`
+
+	// Дата, после которой промокод не может быть активирован. Если не задана, промокод действует бессрочно.
+	ExpiresAt null.Time `json:"expiresAt"`
+
+	// Дата создания промокода.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Дата последнего обновления промокода.
+	UpdatedAt null.Time `json:"updatedAt"`
+}
+
+// IsExpired возвращает true, если срок действия промокода истёк к моменту now.
+func (p *PromoCode) IsExpired(now time.Time) bool {
+	return p.ExpiresAt.Valid && !now.Before(p.ExpiresAt.Time)
+}
+
+// IsExhausted возвращает true, если промокод активирован максимальное количество раз.
+func (p *PromoCode) IsExhausted() bool {
+	return p.MaxUses.Valid && p.Uses >= p.MaxUses.Int64
+}
+
+// AppliesToTariff возвращает true, если промокод действует на серверы с тарифом tariffID.
+func (p *PromoCode) AppliesToTariff(tariffID string) bool {
+	if len(p.TariffIDs) == 0 {
+		return true
+	}
+
+	for _, id := range p.TariffIDs {
+		if id == tariffID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PromoCodeForm - параметры создания или изменения промокода.
+type PromoCodeForm struct {
+	// Промокод. Используется только при создании промокода.
+	Code string `json:"code,omitempty"`
+
+	// Скидка, которую даёт промокод. Поле PromoCode скидки игнорируется.
+	Discount Discount `json:"discount"`
+
+	// Идентификаторы тарифов, на которые действует промокод. Если пуст, промокод действует на любые серверы.
+	TariffIDs []string `json:"tariffIds"`
+
+	// Максимальное количество активаций промокода. Если не задано, количество не ограничено.
+	MaxUses null.Int `json:"maxUses"`
+
+	// Дата, после которой промокод не может быть активирован. Если не задана, промокод действует бессрочно.
+	ExpiresAt null.Time `json:"expiresAt"`
+}
+
+// Validate проверяет параметры скидки.
+func (f *PromoCodeForm) Validate() error {
+	switch f.Discount.Type {
+	case DiscountPercentage:
+		if f.Discount.Percent <= 0 || f.Discount.Percent > 100 {
+			return fmt.Errorf("discount percent must be in (0, 100], got %v", f.Discount.Percent)
+		}
+	case DiscountFixed:
+		if !f.Discount.Amount.Valid || f.Discount.Amount.IsNegative() || f.Discount.Amount.IsZero() {
+			return errors.New("fixed discount amount must be positive")
+		}
+	default:
+		return fmt.Errorf("unsupported discount type: %q", f.Discount.Type)
+	}
+
+	if f.MaxUses.Valid && f.MaxUses.Int64 <= 0 {
+		return errors.New("max uses must be positive")
+	}
+
+	return nil
+}
+
+// PromoCodeRedemption - результат активации промокода пользователем.
+type PromoCodeRedemption struct {
+	// Активированный промокод.
+	Code string `json:"code"`
+
+	// Скидка, полученная пользователем.
+	Discount Discount `json:"discount"`
+
+	// Дата активации.
+	RedeemedAt time.Time `json:"redeemedAt"`
+}
+
+// promoCodeRedemptionForm - тело запроса активации промокода.
+type promoCodeRedemptionForm struct {
+	Code string `json:"code"`
+}
+
+// RedeemPromoCode активирует промокод для текущего пользователя. Полученная скидка будет применена к стоимости
+// подходящих серверов (см. ServerCost.Discount и ServicePricing.Discount). Вернёт ошибку 409, если промокод уже
+// активирован пользователем, и ошибку 410, если срок его действия истёк или он активирован максимальное
+// количество раз.
+func (c *Client) RedeemPromoCode(code string) (*PromoCodeRedemption, error) {
+	return InvokeEndpoint[PromoCodeRedemption](c, http.MethodPost, fmt.Sprintf("/users/%s/promo-codes", c.getCurrentUserReference()), promoCodeRedemptionForm{Code: code})
+}
+
+// GetPromoCodes получает список всех промокодов.
+func (c *Client) GetPromoCodes() (*[]PromoCode, error) {
+	return InvokeEndpoint[[]PromoCode](c, http.MethodGet, "/promo-codes", nil)
+}
+
+// GetPromoCode получает промокод по его значению.
+func (c *Client) GetPromoCode(code string) (*PromoCode, error) {
+	return InvokeEndpoint[PromoCode](c, http.MethodGet, fmt.Sprintf("/promo-codes/%s", code), nil)
+}
+
+// CreatePromoCode создаёт промокод. Вернёт ошибку 409, если промокод с таким значением уже существует.
+func (c *Client) CreatePromoCode(form PromoCodeForm) (*PromoCode, error) {
+	if form.Code == "" {
+		return nil, errors.New("promo code is not specified")
+	}
+
+	err := form.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return InvokeEndpoint[PromoCode](c, http.MethodPost, "/promo-codes", form)
+}
+
+// UpdatePromoCode изменяет параметры промокода. Изменения не затрагивают скидки, уже полученные пользователями.
+func (c *Client) UpdatePromoCode(code string, form PromoCodeForm) (*PromoCode, error) {
+	err := form.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	form.Code = ""
+	return InvokeEndpoint[PromoCode](c, http.MethodPut, fmt.Sprintf("/promo-codes/%s", code), form)
+}
+
+// DeletePromoCode удаляет промокод. Скидки, уже полученные пользователями, продолжают действовать.
+func (c *Client) DeletePromoCode(code string) error {
+	return InvokeVoidEndpoint(c, http.MethodDelete, fmt.Sprintf("/promo-codes/%s", code), nil)
+}
@@ -0,0 +1,501 @@
+package superhub
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Переменные окружения, из которых читаются учётные данные и настройки клиента.
+const (
+	EnvTokenID         = "SUPERHUB_TOKEN_ID"
+	EnvAccessToken     = "SUPERHUB_ACCESS_TOKEN"
+	EnvProfile         = "SUPERHUB_PROFILE"
+	EnvCredentialsFile = "SUPERHUB_CREDENTIALS_FILE"
+	EnvStoreFile       = "SUPERHUB_CREDENTIALS_STORE"
+	EnvStorePassphrase = "SUPERHUB_CREDENTIALS_PASSPHRASE"
+	EnvBaseURL         = "SUPERHUB_BASE_URL"
+)
+
+// DefaultProfile - профиль учётных данных, который используется, если он не задан явно.
+const DefaultProfile = "default"
+
+// ErrNoCredentials возвращается источником учётных данных, если в нём нет учётных данных.
+var ErrNoCredentials = errors.New("no credentials found")
+
+// CredentialsProvider - источник учётных данных.
+type CredentialsProvider interface {
+	// RetrieveCredentials получает учётные данные. Возвращает ErrNoCredentials, если в источнике их нет.
+	RetrieveCredentials() (Credentials, error)
+}
+
+// CredentialsChain - цепочка источников учётных данных, которые опрашиваются по порядку. Возвращаются учётные данные
+// первого источника, в котором они есть. Если источник вернул ошибку, отличную от ErrNoCredentials (например,
+// файл учётных данных повреждён), опрос прекращается.
+type CredentialsChain []CredentialsProvider
+
+func (c CredentialsChain) RetrieveCredentials() (Credentials, error) {
+	for _, provider := range c {
+		credentials, err := provider.RetrieveCredentials()
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+
+		return credentials, err
+	}
+
+	return nil, ErrNoCredentials
+}
+
+// DefaultCredentialsChain возвращает цепочку источников в порядке: переменные окружения, файл учётных данных,
+// зашифрованное хранилище. Профиль для файла и хранилища задаётся переменной окружения EnvProfile.
+func DefaultCredentialsChain() CredentialsChain {
+	return CredentialsChain{
+		EnvironmentCredentials{},
+		&FileCredentials{},
+		&EncryptedCredentialsStore{},
+	}
+}
+
+// NewClientFromEnvironment создаёт клиента с учётными данными из цепочки DefaultCredentialsChain. Адрес API
+// можно переопределить переменной окружения EnvBaseURL.
+func NewClientFromEnvironment() (*Client, error) {
+	credentials, err := DefaultCredentialsChain().RetrieveCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClientWithCredentials(credentials)
+	client.BaseURL = os.Getenv(EnvBaseURL)
+	return client, nil
+}
+
+// getEnvironmentProfile возвращает профиль, заданный явно, или профиль из переменной окружения EnvProfile.
+func getEnvironmentProfile(profile string) string {
+	if profile != "" {
+		return profile
+	}
+
+	if profile = os.Getenv(EnvProfile); profile != "" {
+		return profile
+	}
+
+	return DefaultProfile
+}
+
+// getConfigPath возвращает путь к файлу в каталоге ~/.config/superhub.
+func getConfigPath(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %s", err)
+	}
+
+	return filepath.Join(home, ".config", "superhub", name), nil
+}
+
+func parsePersistentToken(id, accessToken string) (*PersistentToken, error) {
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("parsing token ID: %s", err)
+	}
+
+	if accessToken == "" {
+		return nil, errors.New("access token is not specified")
+	}
+
+	return NewPersistentToken(parsedID, accessToken), nil
+}
+
+// EnvironmentCredentials получает постоянный токен из переменных окружения EnvTokenID и EnvAccessToken.
+type EnvironmentCredentials struct{}
+
+func (EnvironmentCredentials) RetrieveCredentials() (Credentials, error) {
+	id, accessToken := os.Getenv(EnvTokenID), os.Getenv(EnvAccessToken)
+	if id == "" && accessToken == "" {
+		return nil, ErrNoCredentials
+	}
+
+	token, err := parsePersistentToken(id, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("environment: %s", err)
+	}
+
+	return token, nil
+}
+
+// FileCredentials получает постоянный токен из файла учётных данных с именованными профилями:
+//
+//	[default]
+//	token_id = 0b9f6b52-5b4e-4cf4-9a58-0c9f3a1d4e21
+//	access_token = ...
+//
+// Строки, начинающиеся с "#" или ";", считаются комментариями.
+type FileCredentials struct {
+	// Путь к файлу. Если не задан, используется переменная окружения EnvCredentialsFile,
+	// а если не задана и она - ~/.config/superhub/credentials.
+	Path string
+
+	// Профиль. Если не задан, используется переменная окружения EnvProfile, а если не задана и она - DefaultProfile.
+	Profile string
+}
+
+// GetPath возвращает путь к файлу учётных данных.
+func (f *FileCredentials) GetPath() (string, error) {
+	if f.Path != "" {
+		return f.Path, nil
+	}
+
+	if path := os.Getenv(EnvCredentialsFile); path != "" {
+		return path, nil
+	}
+
+	return getConfigPath("credentials")
+}
+
+func (f *FileCredentials) RetrieveCredentials() (Credentials, error) {
+	path, err := f.GetPath()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoCredentials
+	} else if err != nil {
+		return nil, fmt.Errorf("opening credentials file: %s", err)
+	}
+	defer file.Close()
+
+	profiles, err := parseCredentialsFile(bufio.NewScanner(file))
+	if err != nil {
+		return nil, fmt.Errorf("parsing credentials file %s: %s", path, err)
+	}
+
+	profile := getEnvironmentProfile(f.Profile)
+	values, ok := profiles[profile]
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	token, err := parsePersistentToken(values["token_id"], values["access_token"])
+	if err != nil {
+		return nil, fmt.Errorf("profile %s in %s: %s", profile, path, err)
+	}
+
+	return token, nil
+}
+
+// parseCredentialsFile разбирает файл учётных данных в значения, сгруппированные по профилям.
+func parseCredentialsFile(scanner *bufio.Scanner) (map[string]map[string]string, error) {
+	profiles := make(map[string]map[string]string)
+	var current map[string]string
+
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if profiles[name] == nil {
+				profiles[name] = make(map[string]string)
+			}
+			current = profiles[name]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", number)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: value outside of profile", number)
+		}
+
+		current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return profiles, scanner.Err()
+}
+
+// Параметры шифрования хранилища учётных данных.
+const (
+	credentialsStoreVersion    = 1
+	credentialsStoreIterations = 600000
+	credentialsStoreSaltSize   = 16
+	credentialsStoreKeySize    = 32
+
+	// Допустимое количество итераций PBKDF2 в файле хранилища. Меньшее значение делает перебор парольной фразы
+	// слишком простым, а большее позволяет повреждённому файлу надолго занять процессор.
+	credentialsStoreMinIterations = 100000
+	credentialsStoreMaxIterations = 10 * credentialsStoreIterations
+)
+
+// ErrInvalidPassphrase возвращается, если зашифрованное хранилище не удалось расшифровать парольной фразой.
+var ErrInvalidPassphrase = errors.New("invalid credentials store passphrase")
+
+// EncryptedCredentialsStore - локальное хранилище постоянных токенов, зашифрованное парольной фразой. Ключ
+// шифрования получается из парольной фразы по алгоритму PBKDF2-HMAC-SHA256, данные шифруются AES-256-GCM.
+type EncryptedCredentialsStore struct {
+	// Путь к файлу хранилища. Если не задан, используется переменная окружения EnvStoreFile,
+	// а если не задана и она - ~/.config/superhub/credentials.enc.
+	Path string
+
+	// Профиль. Если не задан, используется переменная окружения EnvProfile, а если не задана и она - DefaultProfile.
+	Profile string
+
+	// Парольная фраза. Если не задана, используется переменная окружения EnvStorePassphrase.
+	Passphrase string
+}
+
+// encryptedCredentialsFile - содержимое файла хранилища.
+type encryptedCredentialsFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// storedToken - постоянный токен в расшифрованном хранилище.
+type storedToken struct {
+	ID          uuid.UUID `json:"id"`
+	AccessToken string    `json:"accessToken"`
+}
+
+// GetPath возвращает путь к файлу хранилища.
+func (s *EncryptedCredentialsStore) GetPath() (string, error) {
+	if s.Path != "" {
+		return s.Path, nil
+	}
+
+	if path := os.Getenv(EnvStoreFile); path != "" {
+		return path, nil
+	}
+
+	return getConfigPath("credentials.enc")
+}
+
+// GetPassphrase возвращает парольную фразу хранилища.
+func (s *EncryptedCredentialsStore) GetPassphrase() string {
+	if s.Passphrase != "" {
+		return s.Passphrase
+	}
+
+	return os.Getenv(EnvStorePassphrase)
+}
+
+// RetrieveCredentials получает постоянный токен профиля из хранилища. Если файла хранилища нет или не задана
+// парольная фраза, возвращает ErrNoCredentials.
+func (s *EncryptedCredentialsStore) RetrieveCredentials() (Credentials, error) {
+	if s.GetPassphrase() == "" {
+		return nil, ErrNoCredentials
+	}
+
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	token, ok := tokens[getEnvironmentProfile(s.Profile)]
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	return NewPersistentToken(token.ID, token.AccessToken), nil
+}
+
+// Save сохраняет постоянный токен в профиль хранилища, заменяя прежний. Если файла хранилища нет, он создаётся
+// с правами доступа только для владельца.
+func (s *EncryptedCredentialsStore) Save(token *PersistentToken) error {
+	return s.update(func(tokens map[string]storedToken) {
+		tokens[getEnvironmentProfile(s.Profile)] = storedToken{ID: token.ID, AccessToken: token.AccessToken}
+	})
+}
+
+// Delete удаляет профиль из хранилища.
+func (s *EncryptedCredentialsStore) Delete() error {
+	return s.update(func(tokens map[string]storedToken) {
+		delete(tokens, getEnvironmentProfile(s.Profile))
+	})
+}
+
+func (s *EncryptedCredentialsStore) update(change func(tokens map[string]storedToken)) error {
+	if s.GetPassphrase() == "" {
+		return errors.New("credentials store passphrase is not specified")
+	}
+
+	tokens, err := s.load()
+	if errors.Is(err, ErrNoCredentials) {
+		tokens = make(map[string]storedToken)
+	} else if err != nil {
+		return err
+	}
+
+	change(tokens)
+	return s.store(tokens)
+}
+
+// load читает и расшифровывает хранилище. Если файла нет, возвращает ErrNoCredentials.
+func (s *EncryptedCredentialsStore) load() (map[string]storedToken, error) {
+	path, err := s.GetPath()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoCredentials
+	} else if err != nil {
+		return nil, fmt.Errorf("reading credentials store: %s", err)
+	}
+
+	var file encryptedCredentialsFile
+	err = json.Unmarshal(content, &file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing credentials store: %s", err)
+	}
+
+	if file.Version != credentialsStoreVersion {
+		return nil, fmt.Errorf("unsupported credentials store version: %d", file.Version)
+	}
+
+	if file.Iterations < credentialsStoreMinIterations || file.Iterations > credentialsStoreMaxIterations {
+		return nil, fmt.Errorf("invalid credentials store iteration count: %d", file.Iterations)
+	}
+
+	aead, err := newCredentialsStoreCipher(s.GetPassphrase(), file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(file.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid credentials store nonce length: %d", len(file.Nonce))
+	}
+
+	plaintext, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+
+	var tokens map[string]storedToken
+	err = json.Unmarshal(plaintext, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("parsing credentials store data: %s", err)
+	}
+
+	return tokens, nil
+}
+
+// store шифрует хранилище с новой солью и записывает его в файл.
+func (s *EncryptedCredentialsStore) store(tokens map[string]storedToken) error {
+	path, err := s.GetPath()
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return fmt.Errorf("encoding credentials store data: %s", err)
+	}
+
+	file := encryptedCredentialsFile{
+		Version:    credentialsStoreVersion,
+		Iterations: credentialsStoreIterations,
+		Salt:       make([]byte, credentialsStoreSaltSize),
+	}
+
+	_, err = rand.Read(file.Salt)
+	if err != nil {
+		return fmt.Errorf("generating salt: %s", err)
+	}
+
+	aead, err := newCredentialsStoreCipher(s.GetPassphrase(), file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, aead.NonceSize())
+	_, err = rand.Read(file.Nonce)
+	if err != nil {
+		return fmt.Errorf("generating nonce: %s", err)
+	}
+	file.Data = aead.Seal(nil, file.Nonce, plaintext, nil)
+
+	content, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("encoding credentials store: %s", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return fmt.Errorf("creating credentials store directory: %s", err)
+	}
+
+	err = os.WriteFile(path, content, 0o600)
+	if err != nil {
+		return fmt.Errorf("writing credentials store: %s", err)
+	}
+
+	return nil
+}
+
+func newCredentialsStoreCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations < 1 {
+		return nil, fmt.Errorf("invalid number of iterations: %d", iterations)
+	}
+
+	key := pbkdf2Key(sha256.New, []byte(passphrase), salt, iterations, credentialsStoreKeySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %s", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2Key получает ключ длиной keyLength из пароля по алгоритму PBKDF2 (RFC 8018) с HMAC на основе хеш-функции
+// newHash.
+func pbkdf2Key(newHash func() hash.Hash, password, salt []byte, iterations, keyLength int) []byte {
+	mac := hmac.New(newHash, password)
+	size := mac.Size()
+
+	key := make([]byte, 0, (keyLength+size-1)/size*size)
+	block := make([]byte, size)
+	for index := uint32(1); len(key) < keyLength; index++ {
+		mac.Reset()
+		mac.Write(salt)
+		_ = binary.Write(mac, binary.BigEndian, index)
+		sum := mac.Sum(block[:0])
+
+		result := make([]byte, size)
+		copy(result, sum)
+		for i := 1; i < iterations; i++ {
+			mac.Reset()
+			mac.Write(sum)
+			sum = mac.Sum(block[:0])
+			for j := range result {
+				result[j] ^= sum[j]
+			}
+		}
+
+		key = append(key, result...)
+	}
+
+	return key[:keyLength]
+}
@@ -0,0 +1,136 @@
+package superhub
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/google/uuid"
+)
+
+func TestCredentialsChain_RetrieveCredentials(t *testing.T) {
+	directory := t.TempDir()
+	t.Setenv(EnvTokenID, "")
+	t.Setenv(EnvAccessToken, "")
+	t.Setenv(EnvProfile, "ci")
+	t.Setenv(EnvCredentialsFile, filepath.Join(directory, "credentials"))
+	t.Setenv(EnvStoreFile, filepath.Join(directory, "credentials.enc"))
+	t.Setenv(EnvStorePassphrase, "correct horse battery staple")
+
+	_, err := DefaultCredentialsChain().RetrieveCredentials()
+	assert.Equal(t, errors.Is(err, ErrNoCredentials), true)
+
+	// Зашифрованное хранилище используется, только если учётных данных нет в файле.
+	stored := NewPersistentToken(uuid.MustParse("0b9f6b52-5b4e-4cf4-9a58-0c9f3a1d4e21"), "stored")
+	err = (&EncryptedCredentialsStore{}).Save(stored)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	credentials, err := DefaultCredentialsChain().RetrieveCredentials()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, credentials, Credentials(stored))
+
+	_, err = (&EncryptedCredentialsStore{Passphrase: "wrong"}).RetrieveCredentials()
+	assert.Equal(t, errors.Is(err, ErrInvalidPassphrase), true)
+
+	content := "# SuperHub\n[default]\ntoken_id = 6f1c2a9e-0d4b-4f7e-8c3a-2b5d9e1f0a7c\naccess_token = default\n\n[ci]\ntoken_id = 3d2e1f0a-9b8c-4d7e-a6f5-4e3d2c1b0a99\naccess_token = ci\n"
+	err = os.WriteFile(filepath.Join(directory, "credentials"), []byte(content), 0o600)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	credentials, err = DefaultCredentialsChain().RetrieveCredentials()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, credentials.(*PersistentToken).AccessToken, "ci")
+
+	// Переменные окружения имеют наивысший приоритет.
+	t.Setenv(EnvTokenID, "6f1c2a9e-0d4b-4f7e-8c3a-2b5d9e1f0a7c")
+	t.Setenv(EnvAccessToken, "environment")
+	t.Setenv(EnvBaseURL, "https://api.example.com/v2")
+
+	client, err := NewClientFromEnvironment()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, client.Credentials.(*PersistentToken).AccessToken, "environment")
+	assert.Equal(t, client.GetBaseURL(), "https://api.example.com/v2")
+}
+
+func TestEncryptedCredentialsStore_Corrupted(t *testing.T) {
+	store := &EncryptedCredentialsStore{Path: filepath.Join(t.TempDir(), "credentials.enc"), Passphrase: "secret"}
+	err := store.Save(NewPersistentToken(uuid.MustParse("0b9f6b52-5b4e-4cf4-9a58-0c9f3a1d4e21"), "stored"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	content, err := os.ReadFile(store.Path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var valid encryptedCredentialsFile
+	err = json.Unmarshal(content, &valid)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	corruptions := map[string]func(file *encryptedCredentialsFile){
+		"empty nonce":         func(file *encryptedCredentialsFile) { file.Nonce = nil },
+		"short nonce":         func(file *encryptedCredentialsFile) { file.Nonce = file.Nonce[:4] },
+		"long nonce":          func(file *encryptedCredentialsFile) { file.Nonce = append(file.Nonce, 0) },
+		"zero iterations":     func(file *encryptedCredentialsFile) { file.Iterations = 0 },
+		"negative iterations": func(file *encryptedCredentialsFile) { file.Iterations = -1 },
+		"too few iterations":  func(file *encryptedCredentialsFile) { file.Iterations = 1000 },
+		"too many iterations": func(file *encryptedCredentialsFile) { file.Iterations = 1 << 31 },
+	}
+
+	for name, corrupt := range corruptions {
+		file := valid
+		file.Nonce = append([]byte(nil), valid.Nonce...)
+		corrupt(&file)
+
+		content, err = json.Marshal(file)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = os.WriteFile(store.Path, content, 0o600)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		_, err = store.RetrieveCredentials()
+		if err == nil || errors.Is(err, ErrNoCredentials) || errors.Is(err, ErrInvalidPassphrase) {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
+func TestPbkdf2Key(t *testing.T) {
+	// Тестовые значения из RFC 6070.
+	key := pbkdf2Key(sha1.New, []byte("password"), []byte("salt"), 4096, 20)
+	assert.Equal(t, hex.EncodeToString(key), "4b007901b765489abead49d926f721d065a429c1")
+
+	key = pbkdf2Key(sha1.New, []byte("passwordPASSWORDpassword"), []byte("saltSALTsaltSALTsaltSALTsaltSALTsalt"), 4096, 25)
+	assert.Equal(t, hex.EncodeToString(key), "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038")
+}
@@ -0,0 +1,499 @@
+package superhub
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// qrQuietZone - ширина пустой рамки вокруг QR-кода в модулях, необходимая для его распознавания.
+const qrQuietZone = 4
+
+// errQRDataTooLong возвращается, если данные не помещаются в QR-код максимальной поддерживаемой версии.
+var errQRDataTooLong = errors.New("data is too long for QR code")
+
+// qrVersion - параметры версии QR-кода для уровня коррекции ошибок M.
+type qrVersion struct {
+	// Количество кодовых слов коррекции ошибок в каждом блоке.
+	ecPerBlock int
+
+	// Количество кодовых слов данных в блоках: сначала короткие блоки, затем длинные.
+	blocks []int
+
+	// Координаты центров выравнивающих узоров.
+	alignment []int
+}
+
+// qrVersions содержит версии с 1 по 10, в которые помещается до 213 байт. Этого достаточно для ссылок otpauth.
+var qrVersions = [...]qrVersion{
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v *qrVersion) dataCodewords() int {
+	total := 0
+	for _, size := range v.blocks {
+		total += size
+	}
+
+	return total
+}
+
+// qrCode - QR-код, закодированный в байтовом режиме с уровнем коррекции ошибок M.
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// encodeQRCode кодирует данные в QR-код наименьшей подходящей версии.
+func encodeQRCode(data []byte) (*qrCode, error) {
+	for number := 1; number <= len(qrVersions); number++ {
+		version := &qrVersions[number-1]
+		countBits := 8
+		if number >= 10 {
+			countBits = 16
+		}
+
+		if 4+countBits+len(data)*8 > version.dataCodewords()*8 {
+			continue
+		}
+
+		var bits qrBitBuffer
+		bits.append(0b0100, 4)
+		bits.append(len(data), countBits)
+		for _, value := range data {
+			bits.append(int(value), 8)
+		}
+
+		capacity := version.dataCodewords() * 8
+		terminator := capacity - len(bits)
+		if terminator > 4 {
+			terminator = 4
+		}
+		bits.append(0, terminator)
+		bits.append(0, (8-len(bits)%8)%8)
+
+		codewords := bits.bytes()
+		for pad := byte(0xEC); len(codewords) < version.dataCodewords(); pad ^= 0xEC ^ 0x11 {
+			codewords = append(codewords, pad)
+		}
+
+		code := newQRCode(number)
+		code.drawCodewords(version.interleave(codewords))
+		code.applyBestMask()
+		return code, nil
+	}
+
+	return nil, errQRDataTooLong
+}
+
+// qrBitBuffer - последовательность битов, из которой собираются кодовые слова данных.
+type qrBitBuffer []bool
+
+func (b *qrBitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b qrBitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	return result
+}
+
+// interleave разбивает кодовые слова данных на блоки, добавляет к каждому блоку кодовые слова коррекции ошибок
+// и перемежает их в порядке, в котором они размещаются в QR-коде.
+func (v *qrVersion) interleave(data []byte) []byte {
+	generator := reedSolomonGenerator(v.ecPerBlock)
+	blocks := make([][]byte, len(v.blocks))
+	corrections := make([][]byte, len(v.blocks))
+
+	offset := 0
+	for i, size := range v.blocks {
+		blocks[i] = data[offset : offset+size]
+		corrections[i] = reedSolomonRemainder(blocks[i], generator)
+		offset += size
+	}
+
+	var result []byte
+	for i := 0; i < v.blocks[len(v.blocks)-1]; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, correction := range corrections {
+			result = append(result, correction[i])
+		}
+	}
+
+	return result
+}
+
+// gfMultiply умножает элементы поля GF(256) с порождающим многочленом x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	var result byte
+	for i := 7; i >= 0; i-- {
+		carry := result >> 7
+		result = result<<1 ^ carry*0x1D
+		result ^= (y >> i & 1) * x
+	}
+
+	return result
+}
+
+// reedSolomonGenerator возвращает коэффициенты порождающего многочлена кода Рида-Соломона степени degree
+// без старшего коэффициента.
+func reedSolomonGenerator(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+// reedSolomonRemainder вычисляет кодовые слова коррекции ошибок для блока данных.
+func reedSolomonRemainder(data, generator []byte) []byte {
+	result := make([]byte, len(generator))
+	for _, value := range data {
+		factor := value ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range generator {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+
+	return result
+}
+
+// newQRCode создаёт QR-код версии number с нарисованными служебными узорами.
+func newQRCode(number int) *qrCode {
+	size := number*4 + 17
+	code := &qrCode{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range code.modules {
+		code.modules[i] = make([]bool, size)
+		code.function[i] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		code.setFunction(6, i, i%2 == 0)
+		code.setFunction(i, 6, i%2 == 0)
+	}
+
+	code.drawFinder(3, 3)
+	code.drawFinder(size-4, 3)
+	code.drawFinder(3, size-4)
+
+	alignment := qrVersions[number-1].alignment
+	for i, x := range alignment {
+		for j, y := range alignment {
+			if i == 0 && j == 0 || i == 0 && j == len(alignment)-1 || i == len(alignment)-1 && j == 0 {
+				continue
+			}
+			code.drawAlignment(x, y)
+		}
+	}
+
+	// Области формата резервируются сейчас и заполняются после выбора маски.
+	code.drawFormat(0)
+
+	if number >= 7 {
+		remainder := number
+		for i := 0; i < 12; i++ {
+			remainder = remainder<<1 ^ remainder>>11*0x1F25
+		}
+
+		bits := number<<12 | remainder
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := size-11+i%3, i/3
+			code.setFunction(a, b, dark)
+			code.setFunction(b, a, dark)
+		}
+	}
+
+	return code
+}
+
+// setFunction устанавливает модуль служебного узора в столбце x и строке y.
+func (c *qrCode) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+// drawFinder рисует поисковый узор с центром в (x, y) вместе с окружающим его разделителем.
+func (c *qrCode) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			if x+dx < 0 || x+dx >= c.size || y+dy < 0 || y+dy >= c.size {
+				continue
+			}
+
+			distance := qrDistance(dx, dy)
+			c.setFunction(x+dx, y+dy, distance != 2 && distance != 4)
+		}
+	}
+}
+
+// drawAlignment рисует выравнивающий узор с центром в (x, y).
+func (c *qrCode) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, qrDistance(dx, dy) != 1)
+		}
+	}
+}
+
+// drawFormat рисует обе копии информации о формате для уровня коррекции ошибок M и маски mask.
+func (c *qrCode) drawFormat(mask int) {
+	remainder := mask
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ remainder>>9*0x537
+	}
+
+	bits := (mask<<10 | remainder) ^ 0x5412
+	bit := func(i int) bool {
+		return bits>>i&1 == 1
+	}
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(i))
+	}
+	c.setFunction(8, 7, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.size-15+i, bit(i))
+	}
+	c.setFunction(8, c.size-8, true)
+}
+
+// drawCodewords размещает кодовые слова зигзагом по парам столбцов справа налево, пропуская служебные модули.
+func (c *qrCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+
+		for vertical := 0; vertical < c.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vertical
+				if (right+1)&2 == 0 {
+					y = c.size - 1 - vertical
+				}
+
+				if c.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+
+				c.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// qrMaskApplies возвращает true, если маска mask инвертирует модуль в столбце x и строке y.
+func qrMaskApplies(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+func (c *qrCode) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if !c.function[y][x] && qrMaskApplies(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// applyBestMask применяет маску с наименьшим штрафом. Повторное применение маски отменяет её.
+func (c *qrCode) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask)
+	}
+
+	c.applyMask(best)
+	c.drawFormat(best)
+}
+
+// penalty оценивает, насколько сложно распознать QR-код, по четырём правилам стандарта ISO/IEC 18004.
+func (c *qrCode) penalty() int {
+	penalty := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return c.modules[x][y]
+		}
+		return c.modules[y][x]
+	}
+
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < c.size; y++ {
+			run := 1
+			var window int
+			for x := 0; x < c.size; x++ {
+				window = window << 1 & 0x7FF
+				if at(x, y, vertical) {
+					window |= 1
+				}
+
+				if x > 0 && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+				} else {
+					run = 1
+				}
+
+				if run == 5 {
+					penalty += 3
+				} else if run > 5 {
+					penalty++
+				}
+
+				// Узоры, похожие на поисковый: 1011101 с четырьмя светлыми модулями с одной из сторон.
+				if x >= 10 && (window == 0b10111010000 || window == 0b00001011101) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+
+			if x+1 < c.size && y+1 < c.size {
+				color := c.modules[y][x]
+				if c.modules[y][x+1] == color && c.modules[y+1][x] == color && c.modules[y+1][x+1] == color {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	total := c.size * c.size
+	deviation := dark*100/total - 50
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	penalty += deviation / 5 * 10
+
+	return penalty
+}
+
+// image возвращает изображение QR-кода, в котором каждый модуль занимает scale x scale пикселей.
+func (c *qrCode) image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+
+	side := (c.size + qrQuietZone*2) * scale
+	palette := color.Palette{color.White, color.Black}
+	result := image.NewPaletted(image.Rect(0, 0, side, side), palette)
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					result.SetColorIndex((x+qrQuietZone)*scale+dx, (y+qrQuietZone)*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// writeQRCodePNG кодирует текст в QR-код и записывает его изображение в формате PNG.
+func writeQRCodePNG(writer io.Writer, text string, scale int) error {
+	code, err := encodeQRCode([]byte(text))
+	if err != nil {
+		return err
+	}
+
+	return png.Encode(writer, code.image(scale))
+}
+
+// qrDistance возвращает расстояние от центра узора до модуля со смещением (dx, dy) в метрике Чебышёва.
+func qrDistance(dx, dy int) int {
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx > dy {
+		return dx
+	}
+
+	return dy
+}
@@ -0,0 +1,155 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DaysPerMonth - количество дней в месяце, которое система использует при пересчёте стоимости между дневным и
+// месячным периодами. Не зависит от количества дней в конкретном календарном месяце.
+const DaysPerMonth = 30
+
+// QuoteRequest содержит параметры, по которым рассчитывается стоимость сервера до его покупки (см. CalculateQuote).
+type QuoteRequest struct {
+	// Набор цен, используемый на ноде, где будет размещён сервер (см. Node.GetPriceSet). Обязателен в тарифном режиме
+	// TariffModeDailyResources.
+	PriceSet *PriceSet
+
+	// Готовый тариф, выбранный для сервера (см. Node.GetTariffSet). Обязателен в тарифном режиме
+	// TariffModeMonthlyTariff.
+	Tariff *Tariff
+
+	// Множитель стоимости сервера на ноде (см. Node.Multiplier).
+	Multiplier float64
+
+	// Тарифный режим сервера.
+	TariffMode ServerTariffMode
+
+	// Период, за который необходимо рассчитать стоимость.
+	Period BillingPeriod
+
+	// Запрошенные ресурсы сервера. Учитываются только в тарифном режиме TariffModeDailyResources.
+	Resources Resources
+
+	// Запрошенные дополнительные возможности сервера. Учитываются только в тарифном режиме TariffModeDailyResources.
+	FeatureLimits FeatureLimits
+
+	// Скидка, которая будет действовать на сервер. Если не задана, стоимость рассчитывается без скидки.
+	Discount *Discount
+}
+
+// Quote - рассчитанная стоимость сервера в рублях.
+type Quote struct {
+	// Стоимость сервера за один день без учёта скидки.
+	Daily Money
+
+	// Стоимость сервера за один месяц без учёта скидки.
+	Monthly Money
+
+	// Стоимость сервера за запрошенный период (см. QuoteRequest.Period) с учётом скидки. Совпадает со значением
+	// ServicePricing.ActualCost, которое вернёт система для сервера с такими же параметрами.
+	Cost Money
+}
+
+// NewQuoteRequest создаёт параметры расчёта стоимости сервера на данной ноде с использованием набора цен priceSet.
+func (n *Node) NewQuoteRequest(priceSet *PriceSet, mode ServerTariffMode, period BillingPeriod) QuoteRequest {
+	return QuoteRequest{
+		PriceSet:   priceSet,
+		Multiplier: n.Multiplier,
+		TariffMode: mode,
+		Period:     period,
+	}
+}
+
+// NewTariffQuoteRequest создаёт параметры расчёта стоимости сервера с тарифом tariff на данной ноде.
+func (n *Node) NewTariffQuoteRequest(tariff *Tariff, period BillingPeriod) QuoteRequest {
+	return QuoteRequest{
+		Tariff:        tariff,
+		Multiplier:    n.Multiplier,
+		TariffMode:    TariffModeMonthlyTariff,
+		Period:        period,
+		Resources:     tariff.Resources,
+		FeatureLimits: tariff.FeatureLimits,
+	}
+}
+
+// CalculateQuote рассчитывает стоимость сервера без обращения к API. В тарифном режиме TariffModeDailyResources
+// стоимость рассчитывается по дневным ценам ресурсов из набора цен, а в режиме TariffModeMonthlyTariff равна месячной
+// стоимости тарифа. Стоимость умножается на множитель ноды, а затем пересчитывается в другой период из расчёта
+// DaysPerMonth дней в месяце. Каждое из значений округляется до копеек.
+func CalculateQuote(request QuoteRequest) (*Quote, error) {
+	if request.Multiplier <= 0 {
+		return nil, fmt.Errorf("invalid multiplier: %v", request.Multiplier)
+	}
+
+	var daily, monthly float64
+	switch request.TariffMode {
+	case TariffModeDailyResources:
+		if request.PriceSet == nil {
+			return nil, errors.New("price set is not specified")
+		}
+
+		daily = request.sum(&request.PriceSet.Daily)
+		monthly = daily * DaysPerMonth
+	case TariffModeMonthlyTariff:
+		if request.Tariff == nil {
+			return nil, errors.New("tariff is not specified")
+		}
+
+		monthly = request.Tariff.Price * request.Multiplier
+		daily = monthly / DaysPerMonth
+	default:
+		return nil, fmt.Errorf("unsupported tariff mode: %q", request.TariffMode)
+	}
+
+	var quote Quote
+	var err error
+	quote.Daily, err = roundCost(daily)
+	if err != nil {
+		return nil, fmt.Errorf("rounding daily cost: %w", err)
+	}
+
+	quote.Monthly, err = roundCost(monthly)
+	if err != nil {
+		return nil, fmt.Errorf("rounding monthly cost: %w", err)
+	}
+
+	switch request.Period {
+	case BillingPeriodDaily:
+		quote.Cost = quote.Daily
+	case BillingPeriodMonthly:
+		quote.Cost = quote.Monthly
+	case BillingPeriodOnce:
+		// Единоразовая оплата производится за период, соответствующий тарифному режиму.
+		if request.TariffMode == TariffModeDailyResources {
+			quote.Cost = quote.Daily
+		} else {
+			quote.Cost = quote.Monthly
+		}
+	default:
+		return nil, fmt.Errorf("unsupported billing period: %q", request.Period)
+	}
+
+	if request.Discount != nil {
+		quote.Cost, err = request.Discount.Apply(quote.Cost)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &quote, nil
+}
+
+func (r *QuoteRequest) sum(prices *ResourcePrices) float64 {
+	total := r.Resources.CPU*prices.CPU +
+		r.Resources.Memory*prices.Memory +
+		r.Resources.Disk*prices.Disk +
+		float64(r.FeatureLimits.Databases)*prices.Database +
+		float64(r.FeatureLimits.Backups)*prices.Backup
+
+	return total * r.Multiplier
+}
+
+func roundCost(cost float64) (Money, error) {
+	return MoneyFromFloat(cost, DefaultCurrency)
+}
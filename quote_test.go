@@ -0,0 +1,156 @@
+package superhub
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+var testPriceSet = &PriceSet{
+	Name:    "default",
+	Daily:   ResourcePrices{CPU: 3.5, Memory: 2.1, Disk: 0.15, Database: 0.5, Backup: 1},
+	Monthly: ResourcePrices{CPU: 99, Memory: 59, Disk: 4, Database: 10, Backup: 25},
+}
+
+func rubles(value float64) Money {
+	money, err := MoneyFromFloat(value, CurrencyRUB)
+	if err != nil {
+		panic(err)
+	}
+
+	return money
+}
+
+// quoteFixtures - ответы API для серверов с различными параметрами: набор цен и тариф ноды, параметры сервера
+// и стоимость, которую вернул GetServerPricing.
+type quoteFixtures struct {
+	PriceSets map[string]*PriceSet `json:"priceSets"`
+	Tariffs   map[string]*Tariff   `json:"tariffs"`
+	Quotes    []struct {
+		Name          string              `json:"name"`
+		Node          Node                `json:"node"`
+		Billing       ServerBillingConfig `json:"billing"`
+		Resources     Resources           `json:"resources"`
+		FeatureLimits FeatureLimits       `json:"featureLimits"`
+		Pricing       ServicePricing      `json:"pricing"`
+	} `json:"quotes"`
+}
+
+func TestCalculateQuote(t *testing.T) {
+	data, err := os.ReadFile("testdata/quotes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fixtures quoteFixtures
+	err = json.Unmarshal(data, &fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fixture := range fixtures.Quotes {
+		t.Run(fixture.Name, func(t *testing.T) {
+			request := QuoteRequest{
+				PriceSet:      fixtures.PriceSets[fixture.Node.PriceSetName],
+				Multiplier:    fixture.Node.Multiplier,
+				TariffMode:    fixture.Billing.TariffMode,
+				Period:        fixture.Billing.Period,
+				Resources:     fixture.Resources,
+				FeatureLimits: fixture.FeatureLimits,
+			}
+			if fixture.Billing.TariffID.Valid {
+				request.Tariff = fixtures.Tariffs[fixture.Billing.TariffID.String]
+			}
+
+			quote, err := CalculateQuote(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, quote.Cost, fixture.Pricing.BaseCost)
+
+			request.Discount = fixture.Pricing.Discount
+			quote, err = CalculateQuote(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, quote.Cost, fixture.Pricing.ActualCost)
+		})
+	}
+}
+
+func TestNode_NewTariffQuoteRequest(t *testing.T) {
+	node := &Node{ID: 1, Multiplier: 1.1}
+	tariff := &Tariff{ID: "standard-2", Resources: Resources{CPU: 2, Memory: 4, Disk: 30}, Price: 450.5}
+
+	quote, err := CalculateQuote(node.NewTariffQuoteRequest(tariff, BillingPeriodMonthly))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Стоимость тарифа не зависит от набора цен: 450.5 * 1.1 = 495.55 в месяц, 495.55 / 30 ≈ 16.52 в день.
+	assert.Equal(t, *quote, Quote{Daily: rubles(16.52), Monthly: rubles(495.55), Cost: rubles(495.55)})
+}
+
+func TestCalculateQuote_InvalidRequest(t *testing.T) {
+	requests := []QuoteRequest{
+		{Multiplier: 1, TariffMode: TariffModeDailyResources, Period: BillingPeriodDaily},
+		{PriceSet: testPriceSet, TariffMode: TariffModeDailyResources, Period: BillingPeriodDaily},
+		{PriceSet: testPriceSet, Multiplier: 1, TariffMode: TariffModeMonthlyTariff, Period: BillingPeriodMonthly},
+		{PriceSet: testPriceSet, Multiplier: 1, TariffMode: "HOURLY", Period: BillingPeriodDaily},
+		{PriceSet: testPriceSet, Multiplier: 1, TariffMode: TariffModeDailyResources, Period: "YEARLY"},
+	}
+
+	for _, request := range requests {
+		_, err := CalculateQuote(request)
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestCalculateQuote_Discount(t *testing.T) {
+	discounts := map[*Discount]Money{
+		{Type: DiscountPercentage, Percent: 15}:                   rubles(563.04),
+		{Type: DiscountPercentage, Percent: 100}:                  rubles(0),
+		{Type: DiscountPercentage, Percent: 150}:                  rubles(0),
+		{Type: DiscountPercentage, Percent: -10}:                  rubles(662.4),
+		{Type: DiscountFixed, Amount: NewNullMoney(rubles(100))}:  rubles(562.4),
+		{Type: DiscountFixed, Amount: NewNullMoney(rubles(1000))}: rubles(0),
+	}
+
+	for discount, expected := range discounts {
+		quote, err := CalculateQuote(QuoteRequest{
+			PriceSet:   testPriceSet,
+			Multiplier: 1.2,
+			TariffMode: TariffModeDailyResources,
+			Period:     BillingPeriodMonthly,
+			Resources:  Resources{CPU: 2, Memory: 4, Disk: 20},
+			Discount:   discount,
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, quote.Cost, expected)
+	}
+
+	invalid := []*Discount{
+		{Type: DiscountPercentage, Percent: math.NaN()},
+		{Type: DiscountFixed, Amount: NewNullMoney(NewMoney(100, "USD"))},
+	}
+
+	for _, discount := range invalid {
+		_, err := CalculateQuote(QuoteRequest{
+			PriceSet:   testPriceSet,
+			Multiplier: 1.2,
+			TariffMode: TariffModeDailyResources,
+			Period:     BillingPeriodMonthly,
+			Resources:  Resources{CPU: 2, Memory: 4, Disk: 20},
+			Discount:   discount,
+		})
+		assert.NotEqual(t, err, nil)
+	}
+}
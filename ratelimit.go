@@ -0,0 +1,320 @@
+package superhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Заголовки, которыми API сообщает об ограничении частоты запросов.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// maxRateLimitedAttempts - сколько раз запрос, отклонённый с ошибкой 429, отправляется повторно, если у клиента
+// есть ограничитель частоты запросов (см. Client.RateLimiter).
+const maxRateLimitedAttempts = 5
+
+// ErrRateLimited возвращается, если API отклонил запрос из-за превышения ограничения частоты запросов.
+// Подробности содержит RateLimitError.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimit - ограничение частоты запросов, о котором API сообщил в заголовках ответа.
+type RateLimit struct {
+	// Количество запросов, разрешённое в текущем периоде.
+	Limit int `json:"limit"`
+
+	// Количество запросов, оставшееся в текущем периоде.
+	Remaining int `json:"remaining"`
+
+	// Время начала следующего периода, когда количество оставшихся запросов восстановится.
+	ResetAt time.Time `json:"resetAt"`
+}
+
+// ParseRateLimit получает ограничение частоты запросов из заголовков ответа. Заголовок RateLimitResetHeader
+// содержит время в секундах Unix. Возвращает false, если API не сообщил об ограничении.
+func ParseRateLimit(header http.Header) (RateLimit, bool) {
+	var limit RateLimit
+	var err error
+
+	remaining := header.Get(RateLimitRemainingHeader)
+	if remaining == "" {
+		return limit, false
+	}
+
+	limit.Remaining, err = strconv.Atoi(remaining)
+	if err != nil {
+		return limit, false
+	}
+
+	limit.Limit, _ = strconv.Atoi(header.Get(RateLimitLimitHeader))
+	if reset, err := strconv.ParseInt(header.Get(RateLimitResetHeader), 10, 64); err == nil {
+		limit.ResetAt = time.Unix(reset, 0)
+	}
+
+	return limit, true
+}
+
+// parseRetryAfter получает задержку из заголовка RetryAfterHeader, который содержит количество секунд или дату.
+// Возвращает false, если заголовка нет или его не удалось разобрать.
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get(RetryAfterHeader)
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if date.After(now) {
+			return date.Sub(now), true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// getRetryAfter возвращает задержку, после которой можно повторить запрос, отклонённый с ошибкой 429: из заголовка
+// RetryAfterHeader, а если его нет - до начала следующего периода ограничения. Возвращает false, если API
+// не сообщил ни того, ни другого.
+func getRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if delay, ok := parseRetryAfter(header, now); ok {
+		return delay, true
+	}
+
+	if limit, ok := ParseRateLimit(header); ok && limit.ResetAt.After(now) {
+		return limit.ResetAt.Sub(now), true
+	}
+
+	return 0, false
+}
+
+// RateLimitError - ошибка 429, которой API отклоняет запросы сверх ограничения частоты запросов.
+// Проверяется с помощью errors.Is(err, ErrRateLimited).
+type RateLimitError struct {
+	// Ограничение, о котором API сообщил в ответе. Пустое, если API не передал заголовки ограничения.
+	RateLimit RateLimit
+
+	// Задержка, после которой запрос можно повторить.
+	RetryAfter time.Duration
+
+	// Тело ошибки. Пустое, если API не передал его.
+	Response *ErrorResponse
+}
+
+func (e *RateLimitError) Error() string {
+	message := fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter)
+	if e.Response != nil {
+		message = fmt.Sprintf("%s: %s", message, e.Response.Message)
+	}
+
+	return message
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+func (e *RateLimitError) Unwrap() error {
+	if e.Response == nil {
+		return nil
+	}
+
+	return e.Response
+}
+
+// newRateLimitError создаёт ошибку по ответу 429. Если задержка не указана, запрос можно повторить после начала
+// следующего периода (см. getRetryAfter).
+func newRateLimitError(response *http.Response, errorResponse *ErrorResponse) *RateLimitError {
+	rateLimitError := &RateLimitError{Response: errorResponse}
+	rateLimitError.RetryAfter, _ = getRetryAfter(response.Header, time.Now())
+	rateLimitError.RateLimit, _ = ParseRateLimit(response.Header)
+
+	return rateLimitError
+}
+
+// RateLimiter - ограничитель частоты запросов клиента по алгоритму «корзины токенов». Запросы делятся на группы
+// по первому сегменту адреса (например, "users" или "servers"), у каждой группы своя корзина. Если токенов
+// в корзине нет или API сообщил, что запросы в текущем периоде закончились, запрос ожидает, а не завершается
+// ошибкой. Может одновременно использоваться из нескольких горутин и несколькими клиентами.
+type RateLimiter struct {
+	mutex    sync.Mutex
+	rate     float64
+	burst    int
+	groups   map[string]*rateLimitGroup
+	settings map[string]rateLimitSettings
+}
+
+// rateLimitSettings - частота запросов, заданная для группы с помощью SetGroupLimit.
+type rateLimitSettings struct {
+	rate  float64
+	burst int
+}
+
+// rateLimitGroup - состояние группы запросов.
+type rateLimitGroup struct {
+	rate, burst  float64
+	tokens       float64
+	updatedAt    time.Time
+	blockedUntil time.Time
+	last         RateLimit
+	hasLast      bool
+}
+
+// NewRateLimiter создаёт ограничитель, который пропускает в каждой группе в среднем rate запросов в секунду
+// и до burst запросов подряд. Если rate не больше нуля, учитываются только ограничения, о которых сообщает API.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:     rate,
+		burst:    burst,
+		groups:   make(map[string]*rateLimitGroup),
+		settings: make(map[string]rateLimitSettings),
+	}
+}
+
+// SetGroupLimit задаёт для группы group отдельную частоту запросов. Вызывается до начала использования ограничителя.
+func (l *RateLimiter) SetGroupLimit(group string, rate float64, burst int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	l.settings[group] = rateLimitSettings{rate: rate, burst: burst}
+	delete(l.groups, group)
+}
+
+func (l *RateLimiter) getGroup(group string, now time.Time) *rateLimitGroup {
+	state, ok := l.groups[group]
+	if !ok {
+		settings, ok := l.settings[group]
+		if !ok {
+			settings = rateLimitSettings{rate: l.rate, burst: l.burst}
+		}
+
+		burst := float64(settings.burst)
+		state = &rateLimitGroup{rate: settings.rate, burst: burst, tokens: burst, updatedAt: now}
+		l.groups[group] = state
+	}
+
+	return state
+}
+
+// reserve занимает токен группы и возвращает время ожидания до отправки запроса.
+func (l *RateLimiter) reserve(group string, now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	state := l.getGroup(group, now)
+	var delay time.Duration
+	if state.rate > 0 {
+		state.tokens += now.Sub(state.updatedAt).Seconds() * state.rate
+		if state.tokens > state.burst {
+			state.tokens = state.burst
+		}
+
+		if state.tokens < 1 {
+			delay = time.Duration((1 - state.tokens) / state.rate * float64(time.Second))
+		}
+		state.tokens--
+	}
+	state.updatedAt = now
+
+	if blocked := state.blockedUntil.Sub(now); blocked > delay {
+		delay = blocked
+	}
+
+	return delay
+}
+
+// Wait ожидает, пока в группе group можно будет отправить запрос, или завершения контекста ctx.
+func (l *RateLimiter) Wait(ctx context.Context, group string) error {
+	delay := l.reserve(group, time.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Update учитывает ограничение, о котором API сообщил в ответе на запрос группы group. Если запросы в текущем
+// периоде закончились, следующие запросы группы ожидают начала следующего периода.
+func (l *RateLimiter) Update(group string, limit RateLimit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	state := l.getGroup(group, time.Now())
+	state.last, state.hasLast = limit, true
+	if limit.Remaining <= 0 && limit.ResetAt.After(state.blockedUntil) {
+		state.blockedUntil = limit.ResetAt
+	}
+}
+
+// block приостанавливает запросы группы group на время delay после ошибки 429.
+func (l *RateLimiter) block(group string, delay time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	state := l.getGroup(group, time.Now())
+	if until := time.Now().Add(delay); until.After(state.blockedUntil) {
+		state.blockedUntil = until
+	}
+}
+
+// GetRateLimit возвращает последнее ограничение, о котором API сообщил для группы group. Возвращает false,
+// если запросов этой группы ещё не было или API не сообщал об ограничении.
+func (l *RateLimiter) GetRateLimit(group string) (RateLimit, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	state, ok := l.groups[group]
+	if !ok || !state.hasLast {
+		return RateLimit{}, false
+	}
+
+	return state.last, true
+}
+
+// GetRateLimit возвращает последнее ограничение частоты запросов, о котором API сообщил для группы group
+// (см. RateLimiter). Возвращает false, если у клиента нет ограничителя или API ещё не сообщал об ограничении.
+func (c *Client) GetRateLimit(group string) (RateLimit, bool) {
+	if c.RateLimiter == nil {
+		return RateLimit{}, false
+	}
+
+	return c.RateLimiter.GetRateLimit(group)
+}
+
+// getRouteGroup возвращает группу запроса для ограничителя частоты запросов: первый сегмент адреса после
+// базового адреса API.
+func (c *Client) getRouteGroup(request *http.Request) string {
+	requestPath := request.URL.Path
+	if baseURL, err := url.Parse(c.GetBaseURL()); err == nil {
+		requestPath = strings.TrimPrefix(requestPath, strings.TrimSuffix(baseURL.Path, "/"))
+	}
+
+	group, _, _ := strings.Cut(strings.TrimPrefix(requestPath, "/"), "/")
+	return group
+}
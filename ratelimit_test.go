@@ -0,0 +1,111 @@
+package superhub
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	limiter := NewRateLimiter(10, 2)
+	now := time.Now()
+
+	assert.Equal(t, limiter.reserve("users", now), time.Duration(0))
+	assert.Equal(t, limiter.reserve("users", now), time.Duration(0))
+	assert.Equal(t, limiter.reserve("users", now), 100*time.Millisecond)
+	assert.Equal(t, limiter.reserve("servers", now), time.Duration(0))
+
+	limiter.Update("servers", RateLimit{Limit: 100, Remaining: 0, ResetAt: now.Add(time.Minute)})
+	assert.Equal(t, limiter.reserve("servers", now), time.Minute)
+
+	limit, ok := limiter.GetRateLimit("servers")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, limit.Limit, 100)
+}
+
+func TestClient_RateLimited(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		current := requests.Add(1)
+		writer.Header().Set(RateLimitLimitHeader, "60")
+		writer.Header().Set(RateLimitRemainingHeader, strconv.Itoa(60-int(current)))
+		writer.Header().Set(RateLimitResetHeader, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		writer.Header().Set("Content-Type", "application/json")
+
+		if current == 1 {
+			writer.Header().Set(RetryAfterHeader, "0")
+			writer.WriteHeader(http.StatusTooManyRequests)
+			_, _ = writer.Write([]byte(`{"error": "Too Many Requests", "status": 429}`))
+			return
+		}
+
+		_, _ = writer.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	// Без ограничителя ответ 429 возвращается как ошибка.
+	client := &Client{BaseURL: server.URL + "/v2"}
+	_, err := client.GetUser(1)
+
+	var rateLimitError *RateLimitError
+	assert.Equal(t, errors.Is(err, ErrRateLimited), true)
+	assert.Equal(t, errors.As(err, &rateLimitError), true)
+	assert.Equal(t, rateLimitError.RateLimit.Remaining, 59)
+
+	// С ограничителем запрос повторяется.
+	requests.Store(0)
+	client.RateLimiter = NewRateLimiter(0, 1)
+	_, err = client.GetUser(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	limit, ok := client.GetRateLimit("users")
+	assert.Equal(t, requests.Load(), int32(2))
+	assert.Equal(t, ok, true)
+	assert.Equal(t, limit.Remaining, 58)
+}
+
+func TestClient_RateLimitedWithoutRetryAfter(t *testing.T) {
+	var mutex sync.Mutex
+	var attempts []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		attempts = append(attempts, time.Now())
+		current := len(attempts)
+		mutex.Unlock()
+
+		writer.Header().Set("Content-Type", "application/json")
+		if current <= 2 {
+			writer.WriteHeader(http.StatusTooManyRequests)
+			_, _ = writer.Write([]byte(`{"error": "Too Many Requests", "status": 429}`))
+			return
+		}
+
+		_, _ = writer.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	// API не сообщил задержку, поэтому запрос повторяется с экспоненциально растущей задержкой, а не сразу.
+	client := &Client{BaseURL: server.URL, RetryDelay: 20 * time.Millisecond, RateLimiter: NewRateLimiter(0, 1)}
+	_, err := client.GetUser(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	assert.Equal(t, len(attempts), 3)
+	assert.Equal(t, attempts[1].Sub(attempts[0]) >= 20*time.Millisecond, true)
+	assert.Equal(t, attempts[2].Sub(attempts[1]) >= 40*time.Millisecond, true)
+}
@@ -0,0 +1,223 @@
+package superhub
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DiscrepancyKind - вид расхождения, обнаруженного при сверке баланса пользователя с историей его платежей.
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyBalanceMismatch - баланс пользователя не совпадает с суммой его завершённых платежей.
+	DiscrepancyBalanceMismatch DiscrepancyKind = "BALANCE_MISMATCH"
+
+	// DiscrepancyCurrencyMismatch - платёж проведён в валюте, отличной от валюты баланса пользователя.
+	DiscrepancyCurrencyMismatch DiscrepancyKind = "CURRENCY_MISMATCH"
+
+	// DiscrepancyDuplicatePayment - в истории платежей несколько раз встречается один и тот же идентификатор.
+	DiscrepancyDuplicatePayment DiscrepancyKind = "DUPLICATE_PAYMENT"
+
+	// DiscrepancyOrphanRefund - возврат ссылается на платёж, которого нет в истории платежей пользователя.
+	DiscrepancyOrphanRefund DiscrepancyKind = "ORPHAN_REFUND"
+
+	// DiscrepancyNegativeBalance - после проведения платежа баланс пользователя стал отрицательным.
+	DiscrepancyNegativeBalance DiscrepancyKind = "NEGATIVE_BALANCE"
+)
+
+// Discrepancy - расхождение, обнаруженное при сверке.
+type Discrepancy struct {
+	// Вид расхождения.
+	Kind DiscrepancyKind `json:"kind"`
+
+	// Идентификатор платежа, с которым связано расхождение. Пустой для расхождений, относящихся ко всему балансу.
+	PaymentID string `json:"paymentId,omitempty"`
+
+	// Сумма расхождения. Для DiscrepancyBalanceMismatch - разница между балансом и суммой платежей,
+	// для остальных видов - сумма платежа или баланс после него.
+	Amount Money `json:"amount"`
+
+	// Описание расхождения.
+	Message string `json:"message"`
+}
+
+// LedgerEntry - платёж, учтённый при сверке, вместе с балансом пользователя после его проведения.
+type LedgerEntry struct {
+	// Учтённый платёж.
+	Payment Payment `json:"payment"`
+
+	// Баланс пользователя после проведения платежа.
+	Balance Money `json:"balance"`
+
+	// Сумма всех учтённых платежей с тем же типом источника, включая данный.
+	SourceBalance Money `json:"sourceBalance"`
+}
+
+// ReconciliationReport - результат сверки баланса пользователя с историей его платежей.
+// Платежи учитываются в порядке создания. Незавершённые платежи и платежи в тестовом режиме не изменяют баланс,
+// поэтому не учитываются, но перечисляются в отчёте отдельно.
+type ReconciliationReport struct {
+	// Идентификатор пользователя.
+	UserID int64 `json:"userId"`
+
+	// Баланс пользователя, который вернул API (см. User.Balance).
+	ReportedBalance Money `json:"reportedBalance"`
+
+	// Баланс, полученный сложением всех учтённых платежей.
+	ComputedBalance Money `json:"computedBalance"`
+
+	// Разница между ReportedBalance и ComputedBalance.
+	Difference Money `json:"difference"`
+
+	// Суммы учтённых платежей по типам источника.
+	BySource map[PaymentSourceType]Money `json:"bySource"`
+
+	// Учтённые платежи в порядке их проведения.
+	Entries []LedgerEntry `json:"entries"`
+
+	// Незавершённые платежи.
+	Incomplete []Payment `json:"incomplete"`
+
+	// Платежи, проведённые в тестовом режиме.
+	TestMode []Payment `json:"testMode"`
+
+	// Обнаруженные расхождения.
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+// IsBalanced возвращает true, если баланс пользователя совпадает с суммой его платежей.
+func (r *ReconciliationReport) IsBalanced() bool {
+	return r.Difference.IsZero()
+}
+
+// ReconcileLedger сверяет баланс пользователя user со списком его платежей payments без обращения к API.
+func ReconcileLedger(user *User, payments []Payment) *ReconciliationReport {
+	balance := user.Balance.Sum
+	report := &ReconciliationReport{
+		UserID:          user.ID,
+		ReportedBalance: balance,
+		ComputedBalance: NewMoney(0, balance.Currency),
+		BySource:        make(map[PaymentSourceType]Money),
+	}
+
+	sorted := make([]Payment, len(payments))
+	copy(sorted, payments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	known := make(map[string]bool, len(sorted))
+	for _, payment := range sorted {
+		if known[payment.ID] {
+			report.addDiscrepancy(DiscrepancyDuplicatePayment, &payment, payment.Amount.Sum,
+				"payment appears more than once")
+			continue
+		}
+		known[payment.ID] = true
+
+		switch {
+		case payment.Mode == PaymentModeTest:
+			report.TestMode = append(report.TestMode, payment)
+		case !payment.Completed:
+			report.Incomplete = append(report.Incomplete, payment)
+		default:
+			report.replay(&payment)
+		}
+	}
+
+	for _, payment := range sorted {
+		if payment.IsRefund() && payment.Source.ID.Valid && !known[payment.Source.ID.String] {
+			report.addDiscrepancy(DiscrepancyOrphanRefund, &payment, payment.Amount.Sum,
+				fmt.Sprintf("refunded payment %s is not in the ledger", payment.Source.ID.String))
+		}
+	}
+
+	report.Difference, _ = report.ReportedBalance.Sub(report.ComputedBalance)
+	if !report.IsBalanced() {
+		report.addDiscrepancy(DiscrepancyBalanceMismatch, nil, report.Difference,
+			fmt.Sprintf("reported balance %s differs from computed balance %s",
+				report.ReportedBalance, report.ComputedBalance))
+	}
+
+	return report
+}
+
+func (r *ReconciliationReport) replay(payment *Payment) {
+	amount := payment.Amount.Sum
+
+	balance, err := r.ComputedBalance.Add(amount)
+	if err != nil {
+		r.addDiscrepancy(DiscrepancyCurrencyMismatch, payment, amount,
+			fmt.Sprintf("payment currency %s differs from balance currency %s", amount.Currency, r.ComputedBalance.Currency))
+		return
+	}
+
+	// Валюта совпадает с валютой баланса, поэтому сложение по источнику не может завершиться ошибкой.
+	sourceBalance, _ := r.BySource[payment.Source.Type].Add(amount)
+
+	r.ComputedBalance = balance
+	r.BySource[payment.Source.Type] = sourceBalance
+	r.Entries = append(r.Entries, LedgerEntry{Payment: *payment, Balance: balance, SourceBalance: sourceBalance})
+
+	if balance.IsNegative() {
+		r.addDiscrepancy(DiscrepancyNegativeBalance, payment, balance, "balance became negative")
+	}
+}
+
+func (r *ReconciliationReport) addDiscrepancy(kind DiscrepancyKind, payment *Payment, amount Money, message string) {
+	discrepancy := Discrepancy{Kind: kind, Amount: amount, Message: message}
+	if payment != nil {
+		discrepancy.PaymentID = payment.ID
+	}
+
+	r.Discrepancies = append(r.Discrepancies, discrepancy)
+}
+
+// Reconcile сверяет баланс данного пользователя с историей его платежей.
+func (u *User) Reconcile(client *Client) (*ReconciliationReport, error) {
+	payments, err := u.GetPayments(client)
+	if err != nil {
+		return nil, fmt.Errorf("getting payments: %s", err)
+	}
+
+	return ReconcileLedger(u, *payments), nil
+}
+
+// ReconcileUser сверяет баланс пользователя с заданным идентификатором с историей его платежей.
+func (c *Client) ReconcileUser(userID int64) (*ReconciliationReport, error) {
+	user, err := c.GetUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %s", err)
+	}
+
+	return user.Reconcile(c)
+}
+
+// ReconcileAllUsers сверяет балансы всех пользователей с историей их платежей. Пользователи без платежей тоже
+// сверяются: ненулевой баланс при пустой истории считается расхождением. Отчёты возвращаются в порядке возрастания
+// идентификатора пользователя.
+func (c *Client) ReconcileAllUsers() ([]*ReconciliationReport, error) {
+	users, err := c.ListAllUsers(UserListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing users: %w", err)
+	}
+
+	payments, err := c.GetPayments()
+	if err != nil {
+		return nil, fmt.Errorf("getting payments: %w", err)
+	}
+
+	byUser := make(map[int64][]Payment)
+	for _, payment := range *payments {
+		byUser[payment.UserID] = append(byUser[payment.UserID], payment)
+	}
+
+	sort.SliceStable(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	reports := make([]*ReconciliationReport, 0, len(users))
+	for i := range users {
+		reports = append(reports, ReconcileLedger(&users[i], byUser[users[i].ID]))
+	}
+
+	return reports, nil
+}
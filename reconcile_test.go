@@ -0,0 +1,104 @@
+package superhub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gopkg.in/guregu/null.v4"
+)
+
+func newTestPayment(id string, minor int64, source PaymentSourceType, completed bool, createdAt time.Time) Payment {
+	return Payment{
+		ID:        id,
+		UserID:    1,
+		Amount:    PaymentAmount{Sum: NewMoney(minor, CurrencyRUB), Currency: CurrencyRUB},
+		Source:    PaymentSource{Type: source},
+		Mode:      PaymentModeProduction,
+		Completed: completed,
+		CreatedAt: createdAt,
+	}
+}
+
+func TestReconcileLedger(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testPayment := newTestPayment("test", 100000, PaymentSourceTopUp, true, start)
+	testPayment.Mode = PaymentModeTest
+
+	refund := newTestPayment("refund", -5000, PaymentSourceRefund, true, start.Add(4*time.Hour))
+	refund.Source.ID = null.StringFrom("missing")
+
+	payments := []Payment{
+		newTestPayment("charge", -3010, PaymentSourceServerService, true, start.Add(2*time.Hour)),
+		newTestPayment("top-up", 50000, PaymentSourceTopUp, true, start.Add(time.Hour)),
+		newTestPayment("pending", 20000, PaymentSourceTopUp, false, start.Add(3*time.Hour)),
+		testPayment,
+		refund,
+	}
+
+	user := &User{ID: 1, Balance: PaymentAmount{Sum: NewMoney(41990, CurrencyRUB), Currency: CurrencyRUB}}
+	report := ReconcileLedger(user, payments)
+
+	assert.Equal(t, report.ComputedBalance, NewMoney(41990, CurrencyRUB))
+	assert.Equal(t, report.IsBalanced(), true)
+	assert.Equal(t, report.BySource[PaymentSourceTopUp], NewMoney(50000, CurrencyRUB))
+	assert.Equal(t, report.BySource[PaymentSourceServerService], NewMoney(-3010, CurrencyRUB))
+	assert.Equal(t, len(report.Entries), 3)
+	assert.Equal(t, report.Entries[0].Payment.ID, "top-up")
+	assert.Equal(t, report.Entries[1].Balance, NewMoney(46990, CurrencyRUB))
+	assert.Equal(t, len(report.Incomplete), 1)
+	assert.Equal(t, len(report.TestMode), 1)
+	assert.Equal(t, len(report.Discrepancies), 1)
+	assert.Equal(t, report.Discrepancies[0].Kind, DiscrepancyOrphanRefund)
+
+	user.Balance.Sum = NewMoney(42000, CurrencyRUB)
+	report = ReconcileLedger(user, payments)
+
+	assert.Equal(t, report.IsBalanced(), false)
+	assert.Equal(t, report.Difference, NewMoney(10, CurrencyRUB))
+	assert.Equal(t, report.Discrepancies[len(report.Discrepancies)-1].Kind, DiscrepancyBalanceMismatch)
+}
+
+func TestClient_ReconcileAllUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		switch request.URL.Path {
+		case "/users":
+			_, _ = writer.Write([]byte(`{"items": [
+				{"id": 2, "name": "Alex", "balance": {"sum": 50, "currency": "RUB"}},
+				{"id": 1, "name": "Steve", "balance": {"sum": 100, "currency": "RUB"}},
+				{"id": 3, "name": "Herobrine", "balance": {"sum": 0, "currency": "RUB"}}
+			], "page": 1, "size": 50, "total": 3}`))
+		case "/payments":
+			_, _ = writer.Write([]byte(`[{"id": "p1", "userId": 1, "amount": {"sum": 100, "currency": "RUB"},
+				"source": {"type": "TOP_UP"}, "mode": "PRODUCTION", "completed": true}]`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	reports, err := client.ReconcileAllUsers()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, len(reports), 3)
+	assert.Equal(t, reports[0].UserID, int64(1))
+	assert.Equal(t, reports[0].IsBalanced(), true)
+
+	// У пользователя 2 нет ни одного платежа, но баланс ненулевой.
+	assert.Equal(t, reports[1].UserID, int64(2))
+	assert.Equal(t, reports[1].IsBalanced(), false)
+	assert.Equal(t, len(reports[1].Discrepancies), 1)
+	assert.Equal(t, reports[1].Discrepancies[0].Kind, DiscrepancyBalanceMismatch)
+	assert.Equal(t, reports[1].Difference, NewMoney(5000, CurrencyRUB))
+
+	assert.Equal(t, reports[2].UserID, int64(3))
+	assert.Equal(t, reports[2].IsBalanced(), true)
+}
@@ -0,0 +1,170 @@
+package superhub
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Referee - пользователь, зарегистрированный по реферальному коду другого пользователя.
+type Referee struct {
+	// Идентификатор пользователя.
+	UserID int64 `json:"userId"`
+
+	// Никнейм пользователя.
+	Name string `json:"name"`
+
+	// Имеет значение true, если пользователь получил бонус за первое пополнение баланса.
+	AcquiredBonus bool `json:"bonus"`
+
+	// Дата регистрации пользователя.
+	RegisteredAt time.Time `json:"registeredAt"`
+}
+
+// RefereeEarnings - доходы по реферальной системе, связанные с одним приглашённым пользователем.
+type RefereeEarnings struct {
+	// Приглашённый пользователь.
+	Referee Referee `json:"referee"`
+
+	// Сумма процентов от пополнений приглашённого пользователя, полученная пригласившим
+	// (платежи с источником PaymentSourceReferral).
+	Earned Money `json:"earned"`
+
+	// Количество платежей, из которых сложилась сумма Earned.
+	Payments int `json:"payments"`
+
+	// Приветственный бонус, полученный приглашённым пользователем
+	// (платежи с источником PaymentSourceReferralWelcomeBonus).
+	WelcomeBonus Money `json:"welcomeBonus"`
+}
+
+// ReferralEarnings - сводка доходов пользователя по реферальной системе.
+type ReferralEarnings struct {
+	// Идентификатор пригласившего пользователя.
+	UserID int64 `json:"userId"`
+
+	// Общая сумма процентов от пополнений приглашённых пользователей.
+	Total Money `json:"total"`
+
+	// Доходы по каждому из приглашённых пользователей в порядке убывания суммы.
+	Referees []RefereeEarnings `json:"referees"`
+
+	// Сумма процентов, которые не удалось связать ни с одним из приглашённых пользователей.
+	Unattributed Money `json:"unattributed"`
+}
+
+// AggregateReferralEarnings сводит доходы пользователя userID по реферальной системе без обращения к API.
+// payments - платежи пригласившего пользователя, refereePayments - платежи приглашённых пользователей по их
+// идентификаторам (могут отсутствовать, тогда приветственные бонусы не учитываются). Учитываются только
+// завершённые платежи, проведённые не в тестовом режиме.
+func AggregateReferralEarnings(userID int64, referees []Referee, payments []Payment, refereePayments map[int64][]Payment) *ReferralEarnings {
+	earnings := &ReferralEarnings{UserID: userID, Referees: make([]RefereeEarnings, len(referees))}
+
+	byID := make(map[string]*RefereeEarnings, len(referees))
+	for i, referee := range referees {
+		earnings.Referees[i].Referee = referee
+		byID[strconv.FormatInt(referee.UserID, 10)] = &earnings.Referees[i]
+
+		for _, payment := range refereePayments[referee.UserID] {
+			if isCountedPayment(&payment) && payment.Source.Type == PaymentSourceReferralWelcomeBonus {
+				earnings.Referees[i].WelcomeBonus, _ = earnings.Referees[i].WelcomeBonus.Add(payment.Amount.Sum)
+			}
+		}
+	}
+
+	for _, payment := range payments {
+		if !isCountedPayment(&payment) || payment.Source.Type != PaymentSourceReferral {
+			continue
+		}
+
+		earnings.Total, _ = earnings.Total.Add(payment.Amount.Sum)
+
+		referee, ok := byID[payment.Source.ID.String]
+		if !payment.Source.ID.Valid || !ok {
+			earnings.Unattributed, _ = earnings.Unattributed.Add(payment.Amount.Sum)
+			continue
+		}
+
+		referee.Earned, _ = referee.Earned.Add(payment.Amount.Sum)
+		referee.Payments++
+	}
+
+	sort.SliceStable(earnings.Referees, func(i, j int) bool {
+		return earnings.Referees[i].Earned.Minor > earnings.Referees[j].Earned.Minor
+	})
+
+	return earnings
+}
+
+func isCountedPayment(payment *Payment) bool {
+	return payment.Completed && payment.Mode != PaymentModeTest
+}
+
+// GetReferees получает список пользователей, зарегистрированных по реферальному коду данного пользователя.
+func (u *User) GetReferees(client *Client) (*[]Referee, error) {
+	return client.GetReferees(u.ID)
+}
+
+// GetReferralEarnings получает сводку доходов данного пользователя по реферальной системе
+// (см. Client.GetReferralEarnings).
+func (u *User) GetReferralEarnings(client *Client) (*ReferralEarnings, error) {
+	return client.GetReferralEarnings(u.ID)
+}
+
+// GetReferees получает список пользователей, зарегистрированных по реферальному коду пользователя с заданным
+// идентификатором.
+func (c *Client) GetReferees(userID int64) (*[]Referee, error) {
+	return InvokeEndpoint[[]Referee](c, http.MethodGet, fmt.Sprintf("/users/%d/referees", userID), nil)
+}
+
+// GetReferralEarnings получает сводку доходов пользователя с заданным идентификатором по реферальной системе.
+// Для подсчёта приветственных бонусов получает платежи каждого из приглашённых пользователей, поэтому выполняет
+// по одному запросу на каждого из них.
+func (c *Client) GetReferralEarnings(userID int64) (*ReferralEarnings, error) {
+	referees, err := c.GetReferees(userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting referees: %s", err)
+	}
+
+	payments, err := c.GetUserPayments(userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting payments: %s", err)
+	}
+
+	refereePayments := make(map[int64][]Payment, len(*referees))
+	for _, referee := range *referees {
+		ledger, err := c.GetUserPayments(referee.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("getting payments of referee %d: %s", referee.UserID, err)
+		}
+
+		refereePayments[referee.UserID] = *ledger
+	}
+
+	return AggregateReferralEarnings(userID, *referees, *payments, refereePayments), nil
+}
+
+// referralCodeForm - тело запросов, связанных с реферальным кодом.
+type referralCodeForm struct {
+	Code string `json:"code"`
+}
+
+// RegenerateReferralCode заменяет реферальный код пользователя с заданным идентификатором на новый случайный код.
+// Старый код перестаёт действовать, но пользователи, уже зарегистрированные по нему, остаются приглашёнными.
+func (c *Client) RegenerateReferralCode(userID int64) (*Referral, error) {
+	return InvokeEndpoint[Referral](c, http.MethodPost, fmt.Sprintf("/users/%d/referral/code", userID), nil)
+}
+
+// SetReferralCode заменяет реферальный код пользователя с заданным идентификатором на code.
+// Вернёт ошибку 409, если такой код уже используется другим пользователем.
+func (c *Client) SetReferralCode(userID int64, code string) (*Referral, error) {
+	return InvokeEndpoint[Referral](c, http.MethodPut, fmt.Sprintf("/users/%d/referral/code", userID), referralCodeForm{Code: code})
+}
+
+// ApplyReferralCode указывает реферальный код пользователя, пригласившего текущего. Код можно указать только один
+// раз и только до первого пополнения баланса; иначе API вернёт ошибку 409.
+func (c *Client) ApplyReferralCode(code string) (*Referral, error) {
+	return InvokeEndpoint[Referral](c, http.MethodPost, fmt.Sprintf("/users/%s/referral", c.getCurrentUserReference()), referralCodeForm{Code: code})
+}
@@ -0,0 +1,132 @@
+package superhub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gopkg.in/guregu/null.v4"
+)
+
+// newReferralPayment создаёт платёж с источником source, связанный с приглашённым пользователем refereeID.
+// Если refereeID пуст, платёж не связан ни с одним пользователем.
+func newReferralPayment(minor int64, source PaymentSourceType, refereeID string) Payment {
+	payment := newTestPayment("", minor, source, true, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if refereeID != "" {
+		payment.Source.ID = null.StringFrom(refereeID)
+	}
+
+	return payment
+}
+
+func TestAggregateReferralEarnings(t *testing.T) {
+	alex := Referee{UserID: 2, Name: "Alex"}
+	steve := Referee{UserID: 3, Name: "Steve"}
+	herobrine := Referee{UserID: 4, Name: "Herobrine"}
+
+	testPayment := newReferralPayment(1000, PaymentSourceReferral, "2")
+	testPayment.Mode = PaymentModeTest
+
+	incompletePayment := newReferralPayment(1000, PaymentSourceReferral, "2")
+	incompletePayment.Completed = false
+
+	testBonus := newReferralPayment(5000, PaymentSourceReferralWelcomeBonus, "")
+	testBonus.Mode = PaymentModeTest
+
+	rub := func(minor int64) Money {
+		return NewMoney(minor, CurrencyRUB)
+	}
+
+	tests := []struct {
+		name            string
+		referees        []Referee
+		payments        []Payment
+		refereePayments map[int64][]Payment
+		expected        ReferralEarnings
+	}{
+		{
+			name:     "no referees",
+			expected: ReferralEarnings{UserID: 1, Referees: []RefereeEarnings{}},
+		},
+		{
+			name:     "attributed payments",
+			referees: []Referee{alex},
+			payments: []Payment{
+				newReferralPayment(1000, PaymentSourceReferral, "2"),
+				newReferralPayment(250, PaymentSourceReferral, "2"),
+			},
+			expected: ReferralEarnings{
+				UserID:   1,
+				Total:    rub(1250),
+				Referees: []RefereeEarnings{{Referee: alex, Earned: rub(1250), Payments: 2}},
+			},
+		},
+		{
+			name:     "unattributed payments",
+			referees: []Referee{alex},
+			payments: []Payment{
+				newReferralPayment(1000, PaymentSourceReferral, "2"),
+				newReferralPayment(300, PaymentSourceReferral, ""),
+				newReferralPayment(200, PaymentSourceReferral, "99"),
+			},
+			expected: ReferralEarnings{
+				UserID:       1,
+				Total:        rub(1500),
+				Referees:     []RefereeEarnings{{Referee: alex, Earned: rub(1000), Payments: 1}},
+				Unattributed: rub(500),
+			},
+		},
+		{
+			name:     "test-mode, incomplete and other payments are excluded",
+			referees: []Referee{alex},
+			payments: []Payment{
+				testPayment,
+				incompletePayment,
+				newReferralPayment(10000, PaymentSourceTopUp, ""),
+				newReferralPayment(-700, PaymentSourceServerService, "2"),
+			},
+			expected: ReferralEarnings{UserID: 1, Referees: []RefereeEarnings{{Referee: alex}}},
+		},
+		{
+			name:     "welcome bonuses",
+			referees: []Referee{alex, steve},
+			refereePayments: map[int64][]Payment{
+				2: {
+					newReferralPayment(5000, PaymentSourceReferralWelcomeBonus, ""),
+					newReferralPayment(10000, PaymentSourceTopUp, ""),
+					testBonus,
+				},
+			},
+			expected: ReferralEarnings{
+				UserID:   1,
+				Referees: []RefereeEarnings{{Referee: alex, WelcomeBonus: rub(5000)}, {Referee: steve}},
+			},
+		},
+		{
+			name:     "sorted by earnings",
+			referees: []Referee{alex, steve, herobrine},
+			payments: []Payment{
+				newReferralPayment(100, PaymentSourceReferral, "2"),
+				newReferralPayment(300, PaymentSourceReferral, "4"),
+				newReferralPayment(100, PaymentSourceReferral, "3"),
+			},
+			expected: ReferralEarnings{
+				UserID: 1,
+				Total:  rub(500),
+				Referees: []RefereeEarnings{
+					{Referee: herobrine, Earned: rub(300), Payments: 1},
+					// При равных суммах сохраняется исходный порядок приглашённых.
+					{Referee: alex, Earned: rub(100), Payments: 1},
+					{Referee: steve, Earned: rub(100), Payments: 1},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			earnings := AggregateReferralEarnings(1, test.referees, test.payments, test.refereePayments)
+			assert.Equal(t, *earnings, test.expected)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+var (
+	// ErrPaymentNotCompleted возвращается при попытке вернуть средства по незавершённому платежу.
+	ErrPaymentNotCompleted = errors.New("payment is not completed")
+
+	// ErrPaymentCompleted возвращается при попытке отменить уже завершённый платёж.
+	ErrPaymentCompleted = errors.New("payment is already completed")
+
+	// ErrRefundExceedsPayment возвращается, если сумма возврата больше суммы исходного платежа.
+	ErrRefundExceedsPayment = errors.New("refund amount exceeds payment amount")
+)
+
+// RefundForm - параметры возврата средств по платежу.
+type RefundForm struct {
+	// Сумма возврата в рублях. Если не задана, возвращается вся сумма платежа.
+	Amount NullMoney `json:"amount"`
+
+	// Описание возврата. Например, номер обращения в поддержку.
+	Description null.String `json:"description"`
+
+	// Ключ идемпотентности (см. IdempotencyKeyHeader). При повторной отправке формы после ошибки необходимо
+	// использовать тот же ключ, иначе возврат может быть проведён дважды. Если не задан, создаётся новый ключ.
+	IdempotencyKey string `json:"-"`
+}
+
+func (f RefundForm) GetIdempotencyKey() string {
+	return f.IdempotencyKey
+}
+
+// IsRefund возвращает true, если платёж является возвратом средств по другому платежу.
+func (p *Payment) IsRefund() bool {
+	return p.Source.Type == PaymentSourceRefund
+}
+
+// Refund возвращает средства по данному платежу. Для пополнений баланса (PaymentSourceTopUp) возврат списывает
+// средства с баланса, для списаний (например, PaymentSourceServerService) - зачисляет их обратно.
+// Возвращает созданный платёж-возврат, источник которого ссылается на данный платёж.
+func (p *Payment) Refund(client *Client, form RefundForm) (*Payment, error) {
+	if !p.Completed {
+		return nil, ErrPaymentNotCompleted
+	}
+
+	if form.Amount.Valid {
+		comparison, err := form.Amount.Abs().Compare(p.Amount.Sum.Abs())
+		if err != nil {
+			return nil, err
+		}
+
+		if comparison > 0 {
+			return nil, ErrRefundExceedsPayment
+		}
+	}
+
+	return client.RefundPayment(p.ID, form)
+}
+
+// Cancel отменяет данный платёж. Отменить можно только незавершённый платёж.
+func (p *Payment) Cancel(client *Client) (*Payment, error) {
+	if p.Completed {
+		return nil, ErrPaymentCompleted
+	}
+
+	return client.CancelPayment(p.ID)
+}
+
+// GetRefunds получает список возвратов, проведённых по данному платежу.
+func (p *Payment) GetRefunds(client *Client) (*[]Payment, error) {
+	return client.GetPaymentRefunds(p.ID)
+}
+
+// RefundPayment возвращает средства по платежу с заданным идентификатором полностью или частично (см. RefundForm).
+// Вернёт ошибку 409, если платёж не завершён или по нему уже возвращена вся сумма.
+func (c *Client) RefundPayment(paymentID string, form RefundForm) (*Payment, error) {
+	return InvokeEndpoint[Payment](c, http.MethodPost, fmt.Sprintf("/payments/%s/refunds", paymentID), form)
+}
+
+// GetPaymentRefunds получает список возвратов, проведённых по платежу с заданным идентификатором.
+func (c *Client) GetPaymentRefunds(paymentID string) (*[]Payment, error) {
+	return InvokeEndpoint[[]Payment](c, http.MethodGet, fmt.Sprintf("/payments/%s/refunds", paymentID), nil)
+}
+
+// CancelPayment отменяет незавершённый платёж с заданным идентификатором. Вернёт ошибку 409, если платёж уже
+// завершён.
+func (c *Client) CancelPayment(paymentID string) (*Payment, error) {
+	return InvokeEndpoint[Payment](c, http.MethodPost, fmt.Sprintf("/payments/%s/cancellation", paymentID), nil)
+}
@@ -0,0 +1,214 @@
+package superhub
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// newRefundServer создаёт тестовый API, который проводит возвраты по платежу "p1" на сумму не больше 100 рублей
+// в сумме и запоминает тела запросов.
+func newRefundServer(bodies *[]string) *httptest.Server {
+	var refunded Money
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		if request.Method != http.MethodPost || request.URL.Path != "/payments/p1/refunds" {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		body, _ := io.ReadAll(request.Body)
+		*bodies = append(*bodies, string(body))
+
+		var form RefundForm
+		_ = json.Unmarshal(body, &form)
+		amount := rubles(100)
+		if form.Amount.Valid {
+			amount = form.Amount.Money
+		}
+
+		total, _ := refunded.Add(amount)
+		if comparison, _ := total.Compare(rubles(100)); comparison > 0 {
+			writer.WriteHeader(http.StatusConflict)
+			_, _ = writer.Write([]byte(`{"error": "Conflict", "message": "payment is already refunded", "status": 409}`))
+			return
+		}
+
+		refunded = total
+		_, _ = writer.Write([]byte(`{"id": "r1", "userId": 1, "amount": {"sum": -` + amount.Decimal() + `, "currency": "RUB"},
+			"source": {"type": "REFUND", "id": "p1"}, "mode": "PRODUCTION", "completed": true}`))
+	}))
+}
+
+func TestPayment_Refund(t *testing.T) {
+	var bodies []string
+	server := newRefundServer(&bodies)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, Credentials: EmptyCredentials{}}
+	payment := &Payment{ID: "p1", Amount: PaymentAmount{Sum: rubles(100), Currency: CurrencyRUB}, Completed: true}
+
+	refund, err := payment.Refund(client, RefundForm{Amount: NewNullMoney(rubles(40))})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, refund.IsRefund(), true)
+	assert.Equal(t, refund.Source.ID.String, "p1")
+	assert.Equal(t, refund.Amount.Sum, rubles(-40))
+
+	refund, err = payment.Refund(client, RefundForm{Amount: NewNullMoney(rubles(60))})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, refund.Amount.Sum, rubles(-60))
+	assert.Equal(t, bodies, []string{
+		`{"amount":40,"description":null}` + "\n",
+		`{"amount":60,"description":null}` + "\n",
+	})
+}
+
+func TestPayment_RefundAlreadyRefunded(t *testing.T) {
+	var bodies []string
+	server := newRefundServer(&bodies)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, Credentials: EmptyCredentials{}}
+	payment := &Payment{ID: "p1", Amount: PaymentAmount{Sum: rubles(100), Currency: CurrencyRUB}, Completed: true}
+
+	_, err := payment.Refund(client, RefundForm{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = payment.Refund(client, RefundForm{})
+	if err == nil {
+		t.Error("expected error for already refunded payment")
+		return
+	}
+
+	assert.Equal(t, strings.Contains(err.Error(), "409 (Conflict)"), true)
+	assert.Equal(t, len(bodies), 2)
+}
+
+func TestPayment_RefundIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		keys = append(keys, request.Header.Get(IdempotencyKeyHeader))
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(`{"id": "r1", "userId": 1, "amount": {"sum": -10, "currency": "RUB"},
+			"source": {"type": "REFUND", "id": "p1"}, "completed": true}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, Credentials: EmptyCredentials{}}
+	payment := &Payment{ID: "p1", Amount: PaymentAmount{Sum: rubles(100), Currency: CurrencyRUB}, Completed: true}
+	form := RefundForm{Amount: NewNullMoney(rubles(10)), IdempotencyKey: "refund-p1-1"}
+
+	// Повторная отправка той же формы отправляет тот же ключ.
+	for i := 0; i < 2; i++ {
+		_, err := payment.Refund(client, form)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	// Без заданного ключа для каждого вызова создаётся новый.
+	for i := 0; i < 2; i++ {
+		_, err := payment.Refund(client, RefundForm{Amount: NewNullMoney(rubles(10))})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	assert.Equal(t, len(keys), 4)
+	assert.Equal(t, keys[0], "refund-p1-1")
+	assert.Equal(t, keys[1], "refund-p1-1")
+	assert.NotEqual(t, keys[2], "")
+	assert.NotEqual(t, keys[2], keys[3])
+}
+
+func TestPayment_RefundValidation(t *testing.T) {
+	var bodies []string
+	server := newRefundServer(&bodies)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, Credentials: EmptyCredentials{}}
+	tests := []struct {
+		name     string
+		payment  Payment
+		form     RefundForm
+		expected error
+	}{
+		{
+			name:     "over-refund",
+			payment:  Payment{ID: "p1", Amount: PaymentAmount{Sum: rubles(100)}, Completed: true},
+			form:     RefundForm{Amount: NewNullMoney(rubles(100.01))},
+			expected: ErrRefundExceedsPayment,
+		},
+		{
+			name:     "over-refund of a charge",
+			payment:  Payment{ID: "p1", Amount: PaymentAmount{Sum: rubles(-100)}, Completed: true},
+			form:     RefundForm{Amount: NewNullMoney(rubles(150))},
+			expected: ErrRefundExceedsPayment,
+		},
+		{
+			name:     "incomplete payment",
+			payment:  Payment{ID: "p1", Amount: PaymentAmount{Sum: rubles(100)}},
+			form:     RefundForm{Amount: NewNullMoney(rubles(10))},
+			expected: ErrPaymentNotCompleted,
+		},
+		{
+			name:     "currency mismatch",
+			payment:  Payment{ID: "p1", Amount: PaymentAmount{Sum: NewMoney(10000, "USD")}, Completed: true},
+			form:     RefundForm{Amount: NewNullMoney(rubles(10))},
+			expected: ErrCurrencyMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := test.payment.Refund(client, test.form)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+	}
+
+	assert.Equal(t, len(bodies), 0)
+}
+
+func TestPayment_Cancel(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		path = request.URL.Path
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(`{"id": "p2", "userId": 1, "amount": {"sum": 50, "currency": "RUB"}, "completed": false}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, Credentials: EmptyCredentials{}}
+
+	_, err := (&Payment{ID: "p1", Completed: true}).Cancel(client)
+	assert.Equal(t, errors.Is(err, ErrPaymentCompleted), true)
+	assert.Equal(t, path, "")
+
+	cancelled, err := (&Payment{ID: "p2"}).Cancel(client)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, path, "/payments/p2/cancellation")
+	assert.Equal(t, cancelled.ID, "p2")
+}
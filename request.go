@@ -3,13 +3,88 @@ package superhub
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
-func InvokeEndpoint[T any](client *Client, method, path string, body any) (*T, error) {
+// IdempotencyKeyHeader - заголовок, по которому API определяет повторную отправку одного и того же запроса.
+// Запросы с одинаковым ключом выполняются не более одного раза. InvokeEndpoint устанавливает ключ для каждого
+// изменяющего запроса (POST, PUT, PATCH, DELETE), а при повторных попытках отправляет запрос с тем же ключом.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RequestOption изменяет HTTP запрос перед его отправкой.
+type RequestOption func(request *http.Request)
+
+// WithHeader устанавливает заголовок name со значением value.
+func WithHeader(name, value string) RequestOption {
+	return func(request *http.Request) {
+		request.Header.Set(name, value)
+	}
+}
+
+// WithIdempotencyKey устанавливает ключ идемпотентности запроса (см. IdempotencyKeyHeader).
+func WithIdempotencyKey(key string) RequestOption {
+	return WithHeader(IdempotencyKeyHeader, key)
+}
+
+// NewIdempotencyKey создаёт новый случайный ключ идемпотентности.
+func NewIdempotencyKey() string {
+	return uuid.NewString()
+}
+
+// IdempotentForm - форма запроса, которая задаёт ключ идемпотентности сама. Если форма передана в InvokeEndpoint
+// в качестве тела изменяющего запроса и возвращает непустой ключ, вместо случайного ключа используется он.
+type IdempotentForm interface {
+	GetIdempotencyKey() string
+}
+
+func getIdempotencyKey(body any) string {
+	if form, ok := body.(IdempotentForm); ok {
+		if key := form.GetIdempotencyKey(); key != "" {
+			return key
+		}
+	}
+
+	return NewIdempotencyKey()
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func InvokeEndpoint[T any](client *Client, method, path string, body any, options ...RequestOption) (*T, error) {
+	response, err := InvokeEndpointWithResponse[T](client, method, path, body, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// InvokeEndpointWithResponse выполняет запрос так же, как InvokeEndpoint, но вместе с данными возвращает сведения
+// об ответе: код состояния, заголовки, идентификатор запроса и время выполнения.
+func InvokeEndpointWithResponse[T any](client *Client, method, path string, body any, options ...RequestOption) (*Response[T], error) {
+	request, err := newEndpointRequest(client, method, path, body, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ProcessRequestWithResponse[T](client, request)
+}
+
+func newEndpointRequest(client *Client, method, path string, body any, options ...RequestOption) (*http.Request, error) {
 	url, err := client.GetEndpointURL(path)
 	if err != nil {
 		return nil, fmt.Errorf("making endpoint URL: %s", err)
@@ -33,11 +108,19 @@ func InvokeEndpoint[T any](client *Client, method, path string, body any) (*T, e
 		request.Header.Set("Content-Type", "application/json")
 	}
 
-	return ProcessRequest[T](client, request)
+	if isMutatingMethod(method) {
+		request.Header.Set(IdempotencyKeyHeader, getIdempotencyKey(body))
+	}
+
+	for _, option := range options {
+		option(request)
+	}
+
+	return request, nil
 }
 
-func InvokeVoidEndpoint(client *Client, method, path string, body any) error {
-	_, err := InvokeEndpoint[struct{}](client, method, path, body)
+func InvokeVoidEndpoint(client *Client, method, path string, body any, options ...RequestOption) error {
+	_, err := InvokeEndpoint[struct{}](client, method, path, body, options...)
 	return err
 }
 
@@ -54,34 +137,153 @@ func marshalRequestBody(data any) (io.Reader, error) {
 }
 
 func ProcessRequest[T any](client *Client, request *http.Request) (*T, error) {
-	client.Credentials.AuthorizeRequest(request)
+	response, err := ProcessRequestWithResponse[T](client, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// ProcessRequestWithResponse отправляет запрос и возвращает данные ответа вместе со сведениями о нём. Если у запроса
+// нет заголовка RequestIDHeader, он устанавливается, чтобы запрос можно было найти в журналах API даже при сетевой
+// ошибке. Все ошибки содержат идентификатор запроса (см. RequestError). Запрос, выполняемый от имени другого
+// пользователя, записывается в журнал аудита один раз, сколько бы раз он ни был отправлен (см. Impersonation.Audit),
+// а ответ и ошибка содержат идентификатор этого пользователя и причину работы от его имени.
+func ProcessRequestWithResponse[T any](client *Client, request *http.Request) (*Response[T], error) {
+	if request.Header.Get(RequestIDHeader) == "" {
+		request.Header.Set(RequestIDHeader, uuid.NewString())
+	}
+
+	var impersonatedUserID int64
+	var impersonationReason string
+	if impersonation, ok := client.GetCredentials().(*Impersonation); ok {
+		impersonation.audit(request)
+		impersonatedUserID, impersonationReason = impersonation.UserID, impersonation.Reason
+	}
 
-	response, err := client.GetHttpClient().Do(request)
+	started := time.Now()
+	response, err := client.authorizeAndDispatch(request)
 	if err != nil {
-		return nil, fmt.Errorf("dispatching request: %s", err)
+		if client.Logger != nil {
+			client.logExchange(request, nil, nil, time.Since(started), err)
+		}
+
+		return nil, &RequestError{
+			RequestID:           request.Header.Get(RequestIDHeader),
+			ImpersonatedUserID:  impersonatedUserID,
+			ImpersonationReason: impersonationReason,
+			Err:                 err,
+		}
+	}
+	defer response.Body.Close()
+
+	var responseBody []byte
+	if client.Logger != nil {
+		responseBody = readLoggedResponseBody(response)
 	}
 
 	data, err := handleResponse[T](response)
+	result := newResponse(request, response, data, time.Since(started))
+	result.ImpersonatedUserID, result.ImpersonationReason = impersonatedUserID, impersonationReason
+	if err != nil {
+		err = &RequestError{
+			RequestID:           result.RequestID,
+			StatusCode:          response.StatusCode,
+			ImpersonatedUserID:  impersonatedUserID,
+			ImpersonationReason: impersonationReason,
+			Err:                 fmt.Errorf("handling response: %w", err),
+		}
+	}
+
+	if client.Logger != nil {
+		client.logExchange(request, response, responseBody, result.Latency, err)
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("handling response: %s", err)
+		return nil, err
 	}
 
-	return data, nil
+	return result, nil
 }
 
+// authorizeAndDispatch авторизует и отправляет запрос. Если API отклонил запрос с ошибкой 401, а учётные данные
+// клиента можно обновить (см. RefreshableCredentials), запрос однократно повторяется с обновлёнными учётными данными.
+func (c *Client) authorizeAndDispatch(request *http.Request) (*http.Response, error) {
+	credentials := c.GetCredentials()
+	credentials.AuthorizeRequest(request)
+
+	response, err := c.dispatchRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("dispatching request: %w", err)
+	}
+
+	refreshable, ok := asRefreshableCredentials(credentials)
+	if !ok || response.StatusCode != http.StatusUnauthorized || !isRewindableRequest(request) {
+		return response, nil
+	}
+
+	discardResponse(response)
+
+	err = refreshable.RefreshRejected(request)
+	if err != nil {
+		return nil, fmt.Errorf("refreshing credentials: %w", err)
+	}
+
+	err = rewindRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials.AuthorizeRequest(request)
+	response, err = c.dispatchRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("dispatching request: %w", err)
+	}
+
+	return response, nil
+}
+
+// ErrorResponse - тело ошибки API. Ошибки в формате RFC 7807 (application/problem+json) приводятся к тем же полям:
+// title - к ErrorName, detail - к Message, instance - к Path.
 type ErrorResponse struct {
 	ErrorName string    `json:"error"`
 	Message   string    `json:"message"`
 	Path      string    `json:"path"`
 	Status    int       `json:"status"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Ссылка на описание типа ошибки. Заполняется только для ошибок в формате RFC 7807.
+	Type string `json:"type"`
+
+	Title    string `json:"title"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
 }
 
 func (e *ErrorResponse) Error() string {
 	return fmt.Sprintf("request error: %d (%s) on path %s: %s", e.Status, e.ErrorName, e.Path, e.Message)
 }
 
+// errorResponseSentinels сопоставляет названия ошибок API с ошибками пакета, которые можно проверить с помощью errors.Is.
+var errorResponseSentinels = map[string]error{
+	"MFA_REQUIRED":     ErrMfaRequired,
+	"INVALID_MFA_CODE": ErrInvalidMfaCode,
+}
+
+// Is позволяет проверить ошибку API с помощью errors.Is, если для её названия есть ошибка пакета
+// (например, ErrMfaRequired).
+func (e *ErrorResponse) Is(target error) bool {
+	sentinel, ok := errorResponseSentinels[e.ErrorName]
+	return ok && sentinel == target
+}
+
 func handleResponse[T any](response *http.Response) (*T, error) {
+	if response.StatusCode == http.StatusTooManyRequests {
+		errorResponse, _ := handleErrorResponse(response)
+		return nil, newRateLimitError(response, errorResponse)
+	}
+
 	if response.StatusCode >= http.StatusBadRequest && response.StatusCode < http.StatusInternalServerError {
 		errorResponse, err := handleErrorResponse(response)
 		if err != nil {
@@ -99,24 +301,63 @@ func handleResponse[T any](response *http.Response) (*T, error) {
 }
 
 func handleErrorResponse(response *http.Response) (*ErrorResponse, error) {
-	return parseResponse[ErrorResponse](response)
+	errorResponse, err := parseResponse[ErrorResponse](response)
+	if err != nil {
+		return nil, err
+	}
+
+	if errorResponse.ErrorName == "" {
+		errorResponse.ErrorName = errorResponse.Title
+	}
+	if errorResponse.Message == "" {
+		errorResponse.Message = errorResponse.Detail
+	}
+	if errorResponse.Path == "" {
+		errorResponse.Path = errorResponse.Instance
+	}
+	if errorResponse.Status == 0 {
+		errorResponse.Status = response.StatusCode
+	}
+
+	return errorResponse, nil
+}
+
+// ErrEmptyResponse возвращается, если API не вернул данные в ответ на запрос, который должен их возвращать.
+var ErrEmptyResponse = errors.New("empty response body")
+
+// isVoidResponse возвращает true, если запрос не возвращает данных (см. InvokeVoidEndpoint).
+func isVoidResponse[T any]() bool {
+	_, ok := any((*T)(nil)).(*struct{})
+	return ok
 }
 
+// parseResponse разбирает тело ответа. Для запросов, которые не возвращают данных, тело игнорируется. Для остальных
+// запросов пустое тело или ответ 204 считаются ошибкой ErrEmptyResponse, поэтому данные никогда не бывают nil
+// без ошибки.
 func parseResponse[T any](response *http.Response) (*T, error) {
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %s", err)
 	}
 
-	contentType := getContentType(response)
-	switch contentType {
-	case "application/json":
-		return parseResponseJSON[T](body)
-	case "":
-		return nil, nil
-	default:
-		return nil, fmt.Errorf("unsupported content type: %q", contentType)
+	if isVoidResponse[T]() {
+		return any(&struct{}{}).(*T), nil
+	}
+
+	if response.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(body)) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyResponse, response.Status)
 	}
+
+	mediaType, err := getMediaType(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isJSONMediaType(mediaType) {
+		return nil, fmt.Errorf("unsupported content type: %q", mediaType)
+	}
+
+	return parseResponseJSON[T](body)
 }
 
 func parseResponseJSON[T any](body []byte) (*T, error) {
@@ -129,6 +370,22 @@ func parseResponseJSON[T any](body []byte) (*T, error) {
 	return &data, nil
 }
 
-func getContentType(response *http.Response) string {
-	return response.Header.Get("Content-Type")
+// getMediaType возвращает тип содержимого ответа без параметров (например, charset).
+func getMediaType(response *http.Response) (string, error) {
+	contentType := response.Header.Get("Content-Type")
+	if contentType == "" {
+		return "", errors.New("response has no content type")
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("parsing content type %q: %s", contentType, err)
+	}
+
+	return mediaType, nil
+}
+
+// isJSONMediaType возвращает true для application/json и типов с суффиксом +json, например application/problem+json.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
 }
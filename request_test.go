@@ -0,0 +1,136 @@
+package superhub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestInvokeEndpoint_RetriesWithSameIdempotencyKey(t *testing.T) {
+	var keys, bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		keys = append(keys, request.Header.Get(IdempotencyKeyHeader))
+		bodies = append(bodies, string(body))
+
+		if len(keys) < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(`{"id": "abc", "userId": 1, "amount": {"sum": 10, "currency": "RUB"}}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, MaxRetries: 3, RetryDelay: time.Millisecond}
+	payment, err := client.CreatePayment(1, PaymentCreationForm{Amount: NewMoney(1000, CurrencyRUB)})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, payment.ID, "abc")
+	assert.Equal(t, len(keys), 3)
+	assert.NotEqual(t, keys[0], "")
+	assert.Equal(t, keys[1], keys[0])
+	assert.Equal(t, keys[2], keys[0])
+	assert.Equal(t, bodies[2], bodies[0])
+}
+
+func TestInvokeEndpoint_CallerSuppliedIdempotencyKey(t *testing.T) {
+	var key string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		key = request.Header.Get(IdempotencyKeyHeader)
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	err := InvokeVoidEndpoint(client, http.MethodPost, "/payments", PaymentCreationForm{IdempotencyKey: "refund-42"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, key, "refund-42")
+
+	err = InvokeVoidEndpoint(client, http.MethodGet, "/payments", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, key, "")
+}
+
+func TestParseResponse_ContentTypes(t *testing.T) {
+	respond := func(status int, contentType, body string) *http.Response {
+		recorder := httptest.NewRecorder()
+		if contentType != "" {
+			recorder.Header().Set("Content-Type", contentType)
+		}
+		recorder.WriteHeader(status)
+		_, _ = recorder.WriteString(body)
+		return recorder.Result()
+	}
+
+	user, err := handleResponse[User](respond(http.StatusOK, "application/json; charset=utf-8", `{"id": 1}`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, user.ID, int64(1))
+
+	_, err = handleResponse[User](respond(http.StatusOK, "", `{"id": 1}`))
+	assert.NotEqual(t, err, nil)
+
+	_, err = handleResponse[User](respond(http.StatusNoContent, "", ""))
+	assert.Equal(t, errors.Is(err, ErrEmptyResponse), true)
+
+	void, err := handleResponse[struct{}](respond(http.StatusNoContent, "", ""))
+	assert.Equal(t, err, nil)
+	assert.NotEqual(t, void, nil)
+
+	_, err = handleResponse[User](respond(http.StatusNotFound, "application/problem+json", `{"type": "about:blank", "title": "Not Found", "detail": "user 2 not found", "instance": "/users/2"}`))
+
+	var errorResponse *ErrorResponse
+	assert.Equal(t, errors.As(err, &errorResponse), true)
+	assert.Equal(t, errorResponse.ErrorName, "Not Found")
+	assert.Equal(t, errorResponse.Message, "user 2 not found")
+	assert.Equal(t, errorResponse.Path, "/users/2")
+	assert.Equal(t, errorResponse.Status, http.StatusNotFound)
+}
+
+func TestProcessRequest_RetryDelayCancelled(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests.Add(1)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/payments", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	client := &Client{BaseURL: server.URL, MaxRetries: 3, RetryDelay: time.Hour}
+	started := time.Now()
+	_, err = ProcessRequest[[]Payment](client, request)
+
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert.Equal(t, time.Since(started) < time.Second, true)
+	assert.Equal(t, requests.Load(), int32(1))
+}
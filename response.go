@@ -0,0 +1,136 @@
+package superhub
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader - заголовок с идентификатором запроса. Клиент устанавливает его для каждого запроса, а API
+// возвращает в ответе. Идентификатор запроса следует указывать при обращении в поддержку.
+const RequestIDHeader = "X-Request-Id"
+
+// ServerTimingHeader - заголовок, в котором API сообщает, сколько времени заняли этапы обработки запроса.
+const ServerTimingHeader = "Server-Timing"
+
+// Response - ответ API вместе с данными (см. InvokeEndpointWithResponse).
+type Response[T any] struct {
+	// Данные ответа.
+	Data *T
+
+	// Код состояния HTTP.
+	StatusCode int
+
+	// Заголовки ответа.
+	Header http.Header
+
+	// Идентификатор запроса: из ответа API, а если API его не вернул - установленный клиентом.
+	RequestID string
+
+	// Время от отправки запроса до получения и разбора ответа, включая повторные попытки.
+	Latency time.Duration
+
+	// Этапы обработки запроса на сервере из заголовка ServerTimingHeader.
+	ServerTiming []ServerTimingMetric
+
+	// Идентификатор пользователя, от имени которого выполнен запрос (см. Impersonation). Нулевой, если запрос
+	// выполнен от имени владельца учётных данных.
+	ImpersonatedUserID int64
+
+	// Причина работы от имени пользователя ImpersonatedUserID.
+	ImpersonationReason string
+}
+
+// GetRateLimit возвращает ограничение частоты запросов, о котором API сообщил в ответе.
+func (r *Response[T]) GetRateLimit() (RateLimit, bool) {
+	return ParseRateLimit(r.Header)
+}
+
+// ServerTimingMetric - этап обработки запроса на сервере.
+type ServerTimingMetric struct {
+	// Название этапа.
+	Name string
+
+	// Длительность этапа. Нулевая, если API её не указал.
+	Duration time.Duration
+
+	// Описание этапа.
+	Description string
+}
+
+func newResponse[T any](request *http.Request, response *http.Response, data *T, latency time.Duration) *Response[T] {
+	requestID := response.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = request.Header.Get(RequestIDHeader)
+	}
+
+	return &Response[T]{
+		Data:         data,
+		StatusCode:   response.StatusCode,
+		Header:       response.Header,
+		RequestID:    requestID,
+		Latency:      latency,
+		ServerTiming: parseServerTiming(response.Header),
+	}
+}
+
+// parseServerTiming разбирает заголовки ServerTimingHeader вида `db;dur=53.2, app;dur=47.2;desc="Render"`.
+func parseServerTiming(header http.Header) []ServerTimingMetric {
+	var metrics []ServerTimingMetric
+	for _, value := range header.Values(ServerTimingHeader) {
+		for _, entry := range strings.Split(value, ",") {
+			parts := strings.Split(entry, ";")
+			metric := ServerTimingMetric{Name: strings.TrimSpace(parts[0])}
+			if metric.Name == "" {
+				continue
+			}
+
+			for _, parameter := range parts[1:] {
+				name, parameterValue, _ := strings.Cut(strings.TrimSpace(parameter), "=")
+				parameterValue = strings.Trim(parameterValue, `"`)
+				switch strings.ToLower(name) {
+				case "dur":
+					if milliseconds, err := strconv.ParseFloat(parameterValue, 64); err == nil {
+						metric.Duration = time.Duration(milliseconds * float64(time.Millisecond))
+					}
+				case "desc":
+					metric.Description = parameterValue
+				}
+			}
+
+			metrics = append(metrics, metric)
+		}
+	}
+
+	return metrics
+}
+
+// RequestError - ошибка выполнения запроса к API с идентификатором запроса. Исходная ошибка доступна с помощью
+// errors.Is и errors.As.
+type RequestError struct {
+	// Идентификатор запроса (см. RequestIDHeader).
+	RequestID string
+
+	// Код состояния HTTP. Нулевой, если ответ не был получен.
+	StatusCode int
+
+	// Идентификатор пользователя, от имени которого выполнялся запрос (см. Impersonation). Нулевой, если запрос
+	// выполнялся от имени владельца учётных данных.
+	ImpersonatedUserID int64
+
+	// Причина работы от имени пользователя ImpersonatedUserID.
+	ImpersonationReason string
+
+	// Исходная ошибка.
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s (request ID %s)", e.Err, e.RequestID)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
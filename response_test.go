@@ -0,0 +1,54 @@
+package superhub
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestInvokeEndpointWithResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		if request.URL.Path == "/users/2" {
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = writer.Write([]byte(`{"error": "Not Found", "message": "user not found", "status": 404}`))
+			return
+		}
+
+		writer.Header().Set(RequestIDHeader, "req-42")
+		writer.Header().Set(ServerTimingHeader, `db;dur=12.5, app;dur=30;desc="Render"`)
+		_, _ = writer.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	response, err := InvokeEndpointWithResponse[User](client, http.MethodGet, "/users/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, response.Data.ID, int64(1))
+	assert.Equal(t, response.StatusCode, http.StatusOK)
+	assert.Equal(t, response.RequestID, "req-42")
+	assert.Equal(t, response.ServerTiming, []ServerTimingMetric{
+		{Name: "db", Duration: 12500 * time.Microsecond},
+		{Name: "app", Duration: 30 * time.Millisecond, Description: "Render"},
+	})
+
+	// Если API не вернул идентификатор запроса, в ошибке указан идентификатор, установленный клиентом.
+	_, err = client.GetUser(2)
+
+	var requestError *RequestError
+	var errorResponse *ErrorResponse
+	assert.Equal(t, errors.As(err, &requestError), true)
+	assert.Equal(t, errors.As(err, &errorResponse), true)
+	assert.Equal(t, requestError.StatusCode, http.StatusNotFound)
+	assert.NotEqual(t, requestError.RequestID, "")
+	assert.Equal(t, strings.Contains(err.Error(), requestError.RequestID), true)
+}
@@ -0,0 +1,140 @@
+package superhub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// dispatchRequest отправляет запрос, повторяя попытки при сетевых ошибках и ошибках сервера, если это разрешено
+// настройками клиента (см. Client.MaxRetries). Все попытки отправляются с одними и теми же заголовками, в том числе
+// с одним и тем же ключом идемпотентности.
+//
+// Если у клиента есть ограничитель частоты запросов (см. Client.RateLimiter), перед каждой попыткой запрос ожидает
+// своей очереди, а запрос, отклонённый с ошибкой 429, повторяется после указанной API задержки (см. getRetryAfter).
+// Если API не указал задержку, она увеличивается вдвое с каждым повтором начиная с Client.GetRetryDelay. Такие
+// повторы не учитываются в Client.MaxRetries.
+//
+// Ожидание перед повторной попыткой прерывается, если контекст запроса отменён.
+func (c *Client) dispatchRequest(request *http.Request) (*http.Response, error) {
+	retryable := isRetryableRequest(request)
+	group := c.getRouteGroup(request)
+
+	attempt, rateLimited := 0, 0
+	for {
+		if c.RateLimiter != nil {
+			err := c.RateLimiter.Wait(request.Context(), group)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		response, err := c.GetHttpClient().Do(request)
+		if c.RateLimiter != nil && err == nil {
+			if limit, ok := ParseRateLimit(response.Header); ok {
+				c.RateLimiter.Update(group, limit)
+			}
+
+			if response.StatusCode == http.StatusTooManyRequests && rateLimited < maxRateLimitedAttempts && isRewindableRequest(request) {
+				rateLimited++
+				delay, ok := getRetryAfter(response.Header, time.Now())
+				if !ok {
+					delay = c.GetRetryDelay() << (rateLimited - 1)
+				}
+
+				c.RateLimiter.block(group, delay)
+				discardResponse(response)
+
+				err = rewindRequestBody(request)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		if !retryable || attempt >= c.MaxRetries || !shouldRetry(response, err) {
+			return response, err
+		}
+
+		if response != nil {
+			discardResponse(response)
+		}
+
+		attempt++
+		err = rewindRequestBody(request)
+		if err != nil {
+			return nil, err
+		}
+
+		err = sleepContext(request.Context(), c.GetRetryDelay()<<(attempt-1))
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext ожидает в течение delay. Вернёт ошибку контекста, если ctx будет отменён раньше.
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// discardResponse дочитывает и закрывает тело ответа, чтобы соединение можно было использовать повторно.
+func discardResponse(response *http.Response) {
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+}
+
+func isRetryableRequest(request *http.Request) bool {
+	if !isRewindableRequest(request) {
+		return false
+	}
+
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return request.Header.Get(IdempotencyKeyHeader) != ""
+	}
+}
+
+// isRewindableRequest возвращает true, если тело запроса можно отправить повторно.
+func isRewindableRequest(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+func shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return response.StatusCode >= http.StatusInternalServerError
+}
+
+func rewindRequestBody(request *http.Request) error {
+	if request.GetBody == nil {
+		return nil
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return fmt.Errorf("rewinding request body: %s", err)
+	}
+
+	request.Body = body
+	return nil
+}
@@ -69,10 +69,23 @@ type Server struct {
 // ServerCost показывает текущую стоимость сервера. Включает в себя базовую стоимость и стоимость заморозки.
 type ServerCost struct {
 	// Базовая стоимость сервера.
-	Base float64 `json:"base"`
+	Base Money `json:"base"`
 
 	// Стоимость заморозки сервера.
-	Freeze null.Float `json:"freeze"`
+	Freeze NullMoney `json:"freeze"`
+
+	// Скидка, действующая на сервер. Имеет значение nil, если скидки нет. Базовая стоимость указана без учёта
+	// скидки (см. Effective).
+	Discount *Discount `json:"discount"`
+}
+
+// Effective возвращает стоимость сервера с учётом скидки (см. Discount.Apply).
+func (c *ServerCost) Effective() (Money, error) {
+	if c.Discount == nil {
+		return c.Base, nil
+	}
+
+	return c.Discount.Apply(c.Base)
 }
 
 // ServerTariffMode - тарифный режим сервера. Отражает одновременно период списаний и способ тарификации.
@@ -263,8 +276,14 @@ func (c *Client) GetExternalServer(internalID int64) (*ExternalServer, error) {
 
 // ServicePricing - структура, содержащая информацию о текущей стоимости конкретной услуги.
 type ServicePricing struct {
-	// Текущая стоимость услуги в рублях.
-	ActualCost float64 `json:"actualCost"`
+	// Текущая стоимость услуги в рублях с учётом скидки.
+	ActualCost Money `json:"actualCost"`
+
+	// Стоимость услуги в рублях без учёта скидки. Совпадает с ActualCost, если скидки нет.
+	BaseCost Money `json:"baseCost"`
+
+	// Скидка, действующая на услугу. Имеет значение nil, если скидки нет.
+	Discount *Discount `json:"discount"`
 
 	// Тип политики ценообразования, которая используется в данный момент для данной услуги.
 	PricingPolicyType PricingPolicyType `json:"pricingPolicyType"`
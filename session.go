@@ -0,0 +1,169 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultSessionRefreshMargin - за сколько до истечения срока действия токена доступа сессия обновляет его заранее.
+const DefaultSessionRefreshMargin = time.Minute
+
+// ErrSessionClosed возвращается при попытке обновить сессию, из которой пользователь вышел.
+var ErrSessionClosed = errors.New("session is closed")
+
+// LoginForm - данные для входа в аккаунт по адресу электронной почты и паролю.
+type LoginForm struct {
+	// Адрес электронной почты пользователя.
+	Email string `json:"email"`
+
+	// Пароль пользователя.
+	Password string `json:"password"`
+
+	// Одноразовый пароль или код восстановления. Необходим, если у пользователя подключена двухфакторная
+	// аутентификация: без него вход завершится ошибкой, для которой errors.Is(err, ErrMfaRequired) = true.
+	MfaCode string `json:"mfaCode,omitempty"`
+}
+
+// SessionTokens - токены сессии пользователя.
+type SessionTokens struct {
+	// Токен доступа, которым авторизуются запросы.
+	AccessToken string `json:"accessToken"`
+
+	// Токен, по которому выдаётся новый токен доступа. При каждом обновлении заменяется новым.
+	RefreshToken string `json:"refreshToken"`
+
+	// Дата истечения срока действия токена доступа.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Session - учётные данные сессии, полученные при входе по адресу электронной почты и паролю (см. Client.Login).
+// Запросы авторизуются заголовком "Authorization: Bearer". Токен доступа обновляется автоматически незадолго до
+// истечения его срока действия, а также если API отклонил запрос с ошибкой 401. Сессия может одновременно
+// использоваться из нескольких горутин: при одновременном истечении токена он обновляется только один раз.
+type Session struct {
+	client *Client
+
+	// За сколько до истечения срока действия токена доступа он обновляется заранее. Если не задано,
+	// используется DefaultSessionRefreshMargin. Изменять следует до начала использования сессии.
+	RefreshMargin time.Duration
+
+	mutex  sync.Mutex
+	tokens SessionTokens
+	closed bool
+}
+
+// NewSession создаёт сессию из ранее полученных токенов, например сохранённых между запусками приложения.
+// Клиент client используется для обновления токенов, его учётные данные при этом не учитываются.
+func NewSession(client *Client, tokens SessionTokens) *Session {
+	return &Session{client: client.withCredentials(EmptyCredentials{}), tokens: tokens}
+}
+
+// GetRefreshMargin возвращает, за сколько до истечения срока действия токена доступа он обновляется заранее.
+func (s *Session) GetRefreshMargin() time.Duration {
+	if s.RefreshMargin <= 0 {
+		return DefaultSessionRefreshMargin
+	}
+
+	return s.RefreshMargin
+}
+
+// GetTokens возвращает текущие токены сессии. Токены меняются при каждом обновлении, поэтому сохранять их
+// для следующего запуска следует непосредственно перед завершением работы.
+func (s *Session) GetTokens() SessionTokens {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.tokens
+}
+
+// AuthorizeRequest авторизует запрос токеном доступа, предварительно обновив его, если срок его действия скоро
+// истечёт. Если обновить токен не удалось, запрос авторизуется прежним токеном: при ответе 401 ProcessRequest
+// повторит попытку обновления и вернёт её ошибку.
+func (s *Session) AuthorizeRequest(request *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.closed && time.Now().Add(s.GetRefreshMargin()).After(s.tokens.ExpiresAt) {
+		_ = s.refresh()
+	}
+
+	request.Header.Set("Authorization", s.authorization())
+}
+
+// RefreshRejected обновляет токен доступа, если запрос request был авторизован текущим токеном. Если токен уже
+// обновлён другой горутиной, повторное обновление не выполняется.
+func (s *Session) RefreshRejected(request *http.Request) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if request.Header.Get("Authorization") != s.authorization() {
+		return nil
+	}
+
+	return s.refresh()
+}
+
+// Refresh обновляет токен доступа независимо от срока его действия.
+func (s *Session) Refresh() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.refresh()
+}
+
+func (s *Session) authorization() string {
+	return fmt.Sprintf("%s %s", "Bearer", s.tokens.AccessToken)
+}
+
+// sessionRefreshForm - тело запроса обновления токена доступа.
+type sessionRefreshForm struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// refresh обновляет токены сессии. Вызывается при захваченном мьютексе.
+func (s *Session) refresh() error {
+	if s.closed {
+		return ErrSessionClosed
+	}
+
+	tokens, err := InvokeEndpoint[SessionTokens](s.client, http.MethodPost, "/sessions/refresh", sessionRefreshForm{RefreshToken: s.tokens.RefreshToken})
+	if err != nil {
+		return err
+	}
+
+	s.tokens = *tokens
+	return nil
+}
+
+// Logout завершает сессию. Токены сессии перестают действовать, а запросы, авторизованные ей, отклоняются.
+func (s *Session) Logout() error {
+	err := InvokeVoidEndpoint(s.client.withCredentials(s), http.MethodDelete, "/sessions/current", nil)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	s.tokens = SessionTokens{}
+	return nil
+}
+
+// Login выполняет вход по адресу электронной почты и паролю и возвращает сессию, которую можно использовать
+// в качестве учётных данных клиента (см. Client.Credentials). Учётные данные самого клиента при входе не учитываются.
+//
+// Если у пользователя подключена двухфакторная аутентификация, а в форме нет одноразового пароля, вернёт ошибку,
+// для которой errors.Is(err, ErrMfaRequired) = true. В этом случае необходимо запросить одноразовый пароль
+// у пользователя и повторить вход с заполненным полем LoginForm.MfaCode.
+func (c *Client) Login(form LoginForm) (*Session, error) {
+	tokens, err := InvokeEndpoint[SessionTokens](c.withCredentials(EmptyCredentials{}), http.MethodPost, "/sessions", form)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSession(c, *tokens), nil
+}
@@ -0,0 +1,84 @@
+package superhub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestClient_Login(t *testing.T) {
+	var mutex sync.Mutex
+	var refreshes int
+	accessToken := "access-0"
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		writer.Header().Set("Content-Type", "application/json")
+		switch request.URL.Path {
+		case "/sessions":
+			var form LoginForm
+			_ = json.NewDecoder(request.Body).Decode(&form)
+			if form.MfaCode == "" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				_, _ = writer.Write([]byte(`{"error": "MFA_REQUIRED", "status": 401}`))
+				return
+			}
+
+			_, _ = fmt.Fprintf(writer, `{"accessToken": %q, "refreshToken": "refresh", "expiresAt": %q}`, accessToken, time.Now().Add(time.Hour).Format(time.RFC3339))
+		case "/sessions/refresh":
+			refreshes++
+			accessToken = fmt.Sprintf("access-%d", refreshes)
+			_, _ = fmt.Fprintf(writer, `{"accessToken": %q, "refreshToken": "refresh", "expiresAt": %q}`, accessToken, time.Now().Add(time.Hour).Format(time.RFC3339))
+		default:
+			if request.Header.Get("Authorization") != "Bearer "+accessToken {
+				writer.WriteHeader(http.StatusUnauthorized)
+				_, _ = writer.Write([]byte(`{"error": "Unauthorized", "status": 401}`))
+				return
+			}
+
+			_, _ = writer.Write([]byte(`{"id": 1}`))
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	_, err := client.Login(LoginForm{Email: "steve@example.com", Password: "secret"})
+	assert.Equal(t, errors.Is(err, ErrMfaRequired), true)
+
+	session, err := client.Login(LoginForm{Email: "steve@example.com", Password: "secret", MfaCode: "123456"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client.Credentials = session
+
+	// Токен отзывается на сервере: параллельные запросы получают ошибку 401, но токен обновляется один раз.
+	mutex.Lock()
+	accessToken = "revoked"
+	mutex.Unlock()
+
+	var group sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			_, err := client.GetCurrentUser()
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	group.Wait()
+
+	assert.Equal(t, refreshes, 1)
+	assert.Equal(t, session.GetTokens().AccessToken, "access-1")
+}
@@ -0,0 +1,167 @@
+package superhub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TokenScope - право доступа, которое предоставляет постоянный токен.
+type TokenScope string
+
+const (
+	// TokenScopeAll предоставляет все права владельца токена.
+	TokenScopeAll TokenScope = "*"
+
+	TokenScopeUsersRead     TokenScope = "users:read"
+	TokenScopeUsersWrite    TokenScope = "users:write"
+	TokenScopeServersRead   TokenScope = "servers:read"
+	TokenScopeServersWrite  TokenScope = "servers:write"
+	TokenScopePaymentsRead  TokenScope = "payments:read"
+	TokenScopePaymentsWrite TokenScope = "payments:write"
+)
+
+// PersistentTokenInfo - сведения о постоянном токене. Секрет токена API возвращает только при его создании.
+type PersistentTokenInfo struct {
+	// Идентификатор токена.
+	ID uuid.UUID `json:"id"`
+
+	// Название токена, по которому пользователь отличает его от других.
+	Name string `json:"name"`
+
+	// Права доступа токена. Запросы, для которых прав недостаточно, отклоняются с ошибкой 403.
+	Scopes []TokenScope `json:"scopes"`
+
+	// Дата истечения срока действия токена. Пустая, если токен бессрочный.
+	ExpiresAt null.Time `json:"expiresAt"`
+
+	// Дата последнего запроса, авторизованного токеном. Пустая, если токен ещё не использовался.
+	LastUsedAt null.Time `json:"lastUsedAt"`
+
+	// Дата создания токена.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IsExpired возвращает true, если срок действия токена истёк.
+func (i *PersistentTokenInfo) IsExpired(now time.Time) bool {
+	return i.ExpiresAt.Valid && !now.Before(i.ExpiresAt.Time)
+}
+
+// HasScope возвращает true, если токен предоставляет право scope.
+func (i *PersistentTokenInfo) HasScope(scope TokenScope) bool {
+	for _, granted := range i.Scopes {
+		if granted == scope || granted == TokenScopeAll {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PersistentTokenForm - параметры создания постоянного токена.
+type PersistentTokenForm struct {
+	// Название токена.
+	Name string `json:"name"`
+
+	// Права доступа токена. Если не заданы, токен предоставляет все права владельца (TokenScopeAll).
+	Scopes []TokenScope `json:"scopes,omitempty"`
+
+	// Дата истечения срока действия токена. Если не задана, токен бессрочный.
+	ExpiresAt null.Time `json:"expiresAt"`
+}
+
+// PersistentTokenUpdateForm - изменение постоянного токена. Пустые поля не изменяются.
+type PersistentTokenUpdateForm struct {
+	// Новое название токена.
+	Name null.String `json:"name"`
+
+	// Новые права доступа токена. Заменяют прежние полностью.
+	Scopes []TokenScope `json:"scopes,omitempty"`
+
+	// Новая дата истечения срока действия токена. Бессрочным токен после этого сделать нельзя.
+	ExpiresAt null.Time `json:"expiresAt"`
+}
+
+// createdPersistentToken - ответ на создание постоянного токена, единственный, в котором есть его секрет.
+type createdPersistentToken struct {
+	PersistentTokenInfo
+	AccessToken string `json:"accessToken"`
+}
+
+// GetInfo получает сведения о данном токене.
+func (p *PersistentToken) GetInfo(client *Client) (*PersistentTokenInfo, error) {
+	return client.GetPersistentToken(p.ID)
+}
+
+// Revoke отзывает данный токен. Запросы, авторизованные им, после этого отклоняются.
+func (p *PersistentToken) Revoke(client *Client) error {
+	return client.RevokePersistentToken(p.ID)
+}
+
+// CreatePersistentToken создаёт постоянный токен текущего пользователя и возвращает его в виде, готовом для
+// использования в качестве учётных данных клиента. Секрет токена больше нельзя получить, поэтому его необходимо
+// сохранить сразу.
+//
+// Например, для кратковременного доступа из CI достаточно токена с правом TokenScopeServersWrite,
+// срок действия которого истекает через час.
+func (c *Client) CreatePersistentToken(form PersistentTokenForm) (*PersistentToken, error) {
+	if form.Name == "" {
+		return nil, errors.New("token name is not specified")
+	}
+
+	if form.ExpiresAt.Valid && !form.ExpiresAt.Time.After(time.Now()) {
+		return nil, errors.New("token expiration date is in the past")
+	}
+
+	created, err := InvokeEndpoint[createdPersistentToken](c, http.MethodPost, fmt.Sprintf("/users/%s/tokens", c.getCurrentUserReference()), form)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPersistentToken(created.ID, created.AccessToken), nil
+}
+
+// GetPersistentTokens получает список постоянных токенов текущего пользователя.
+func (c *Client) GetPersistentTokens() (*[]PersistentTokenInfo, error) {
+	return InvokeEndpoint[[]PersistentTokenInfo](c, http.MethodGet, fmt.Sprintf("/users/%s/tokens", c.getCurrentUserReference()), nil)
+}
+
+// GetPersistentToken получает сведения о постоянном токене текущего пользователя.
+func (c *Client) GetPersistentToken(id uuid.UUID) (*PersistentTokenInfo, error) {
+	return InvokeEndpoint[PersistentTokenInfo](c, http.MethodGet, fmt.Sprintf("/users/%s/tokens/%s", c.getCurrentUserReference(), id), nil)
+}
+
+// UpdatePersistentToken изменяет название, права доступа или срок действия постоянного токена текущего пользователя.
+// Секрет токена при этом не меняется.
+func (c *Client) UpdatePersistentToken(id uuid.UUID, form PersistentTokenUpdateForm) (*PersistentTokenInfo, error) {
+	return InvokeEndpoint[PersistentTokenInfo](c, http.MethodPatch, fmt.Sprintf("/users/%s/tokens/%s", c.getCurrentUserReference(), id), form)
+}
+
+// RenamePersistentToken изменяет название постоянного токена текущего пользователя.
+func (c *Client) RenamePersistentToken(id uuid.UUID, name string) (*PersistentTokenInfo, error) {
+	return c.UpdatePersistentToken(id, PersistentTokenUpdateForm{Name: null.StringFrom(name)})
+}
+
+// SetPersistentTokenScopes заменяет права доступа постоянного токена текущего пользователя.
+func (c *Client) SetPersistentTokenScopes(id uuid.UUID, scopes ...TokenScope) (*PersistentTokenInfo, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("token scopes are not specified")
+	}
+
+	return c.UpdatePersistentToken(id, PersistentTokenUpdateForm{Scopes: scopes})
+}
+
+// ExpirePersistentToken устанавливает дату истечения срока действия постоянного токена текущего пользователя.
+// Чтобы отозвать токен немедленно, используйте RevokePersistentToken.
+func (c *Client) ExpirePersistentToken(id uuid.UUID, expiresAt time.Time) (*PersistentTokenInfo, error) {
+	return c.UpdatePersistentToken(id, PersistentTokenUpdateForm{ExpiresAt: null.TimeFrom(expiresAt)})
+}
+
+// RevokePersistentToken отзывает постоянный токен текущего пользователя.
+func (c *Client) RevokePersistentToken(id uuid.UUID) error {
+	return InvokeVoidEndpoint(c, http.MethodDelete, fmt.Sprintf("/users/%s/tokens/%s", c.getCurrentUserReference(), id), nil)
+}
@@ -0,0 +1,40 @@
+package superhub
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestClient_CreatePersistentToken(t *testing.T) {
+	var form PersistentTokenForm
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_ = json.NewDecoder(request.Body).Decode(&form)
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(`{"id": "0b9f6b52-5b4e-4cf4-9a58-0c9f3a1d4e21", "name": "ci", "accessToken": "secret"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	token, err := client.CreatePersistentToken(PersistentTokenForm{
+		Name:      "ci",
+		Scopes:    []TokenScope{TokenScopeServersWrite},
+		ExpiresAt: null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, form.Scopes, []TokenScope{TokenScopeServersWrite})
+	assert.Equal(t, token.ID.String(), "0b9f6b52-5b4e-4cf4-9a58-0c9f3a1d4e21")
+	assert.Equal(t, token.AccessToken, "secret")
+
+	info := &PersistentTokenInfo{Scopes: []TokenScope{TokenScopeAll}}
+	assert.Equal(t, info.HasScope(TokenScopePaymentsWrite), true)
+}
@@ -1,16 +1,26 @@
 package superhub
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"gopkg.in/guregu/null.v4"
 )
 
+// CurrentUserReference - ссылка на владельца учётных данных в адресах запросов. При работе от имени другого
+// пользователя (см. Impersonation) методы клиента заменяют её идентификатором этого пользователя.
 const CurrentUserReference = "@self"
 
+// DefaultUserPageSize - количество пользователей на странице, если размер страницы не задан явно.
+const DefaultUserPageSize = 50
+
+// ErrUserModified возвращается при обновлении пользователя, если он был изменён после того, как клиент его получил.
+var ErrUserModified = errors.New("user was modified concurrently")
+
 // User - пользователь, зарегистрированный на сайте хостинга. Поля отражают основные параметры, используемые в ЛК.
 type User struct {
 	// Идентификатор пользователя в системе.
@@ -52,9 +62,21 @@ type User struct {
 
 	// Дата регистрации пользователя.
 	CreatedAt time.Time `json:"createdAt"`
+
+	// Дата последнего изменения пользователя. Пустая, если пользователь не изменялся с момента регистрации.
 	UpdatedAt null.Time `json:"updatedAt"`
 }
 
+// GetVersion возвращает дату последнего изменения пользователя, а если он не изменялся - дату регистрации.
+// Используется для проверки одновременного изменения (см. UserUpdateForm).
+func (u *User) GetVersion() time.Time {
+	if u.UpdatedAt.Valid {
+		return u.UpdatedAt.Time
+	}
+
+	return u.CreatedAt
+}
+
 // LinkedDiscord содержит информацию о привязанном аккаунте пользователя в Discord.
 type LinkedDiscord struct {
 	// Идентификатор пользователя в Discord.
@@ -122,6 +144,147 @@ func (u *User) CreatePayment(client *Client, form PaymentCreationForm) (*Payment
 	return client.CreatePayment(u.ID, form)
 }
 
+// Update изменяет данного пользователя. Если в форме не задана версия ExpectedVersion, используется версия данного
+// пользователя, поэтому изменения, сделанные после его получения, не будут перезаписаны: в этом случае возвращается
+// ошибка ErrUserModified. При успешном обновлении структура заменяется обновлённым пользователем.
+func (u *User) Update(client *Client, form UserUpdateForm) error {
+	if !form.ExpectedVersion.Valid {
+		form.ExpectedVersion = null.TimeFrom(u.GetVersion())
+	}
+
+	updated, err := client.UpdateUser(u.ID, form)
+	if err != nil {
+		return err
+	}
+
+	*u = *updated
+	return nil
+}
+
+// UserSortField - поле, по которому сортируется список пользователей.
+type UserSortField string
+
+const (
+	UserSortByID        UserSortField = "id"
+	UserSortByEmail     UserSortField = "email"
+	UserSortByName      UserSortField = "name"
+	UserSortByBalance   UserSortField = "balance"
+	UserSortByCreatedAt UserSortField = "createdAt"
+)
+
+// SortOrder - направление сортировки.
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// UserListOptions - параметры поиска и постраничного вывода пользователей. Пустые поля не учитываются,
+// условия по нескольким полям объединяются.
+type UserListOptions struct {
+	// Строка поиска по адресу электронной почты и никнейму. Ищется вхождение без учёта регистра.
+	Query string
+
+	// Точный адрес электронной почты.
+	Email string
+
+	// Точный никнейм.
+	Name string
+
+	// Идентификатор привязанного аккаунта Discord.
+	DiscordID string
+
+	// Идентификатор привязанного аккаунта ВК.
+	VKID int64
+
+	// Поле сортировки. По умолчанию пользователи сортируются по идентификатору.
+	Sort UserSortField
+
+	// Направление сортировки. По умолчанию - по возрастанию.
+	Order SortOrder
+
+	// Номер страницы, начиная с 1. По умолчанию - первая страница.
+	Page int
+
+	// Количество пользователей на странице. По умолчанию - DefaultUserPageSize.
+	PageSize int
+}
+
+func (o *UserListOptions) values() url.Values {
+	values := url.Values{}
+	if o.Query != "" {
+		values.Set("query", o.Query)
+	}
+	if o.Email != "" {
+		values.Set("email", o.Email)
+	}
+	if o.Name != "" {
+		values.Set("name", o.Name)
+	}
+	if o.DiscordID != "" {
+		values.Set("discordId", o.DiscordID)
+	}
+	if o.VKID != 0 {
+		values.Set("vkId", strconv.FormatInt(o.VKID, 10))
+	}
+	if o.Sort != "" {
+		values.Set("sort", string(o.Sort))
+	}
+	if o.Order != "" {
+		values.Set("order", string(o.Order))
+	}
+
+	page := o.Page
+	if page < 1 {
+		page = 1
+	}
+	values.Set("page", strconv.Itoa(page))
+
+	pageSize := o.PageSize
+	if pageSize < 1 {
+		pageSize = DefaultUserPageSize
+	}
+	values.Set("size", strconv.Itoa(pageSize))
+
+	return values
+}
+
+// UserPage - страница списка пользователей.
+type UserPage struct {
+	// Пользователи на текущей странице.
+	Users []User `json:"items"`
+
+	// Номер текущей страницы, начиная с 1.
+	Page int `json:"page"`
+
+	// Размер страницы.
+	PageSize int `json:"size"`
+
+	// Общее количество пользователей, подходящих под условия поиска.
+	Total int64 `json:"total"`
+}
+
+// HasNextPage возвращает true, если после данной страницы есть ещё пользователи.
+func (p *UserPage) HasNextPage() bool {
+	return int64(p.Page)*int64(p.PageSize) < p.Total
+}
+
+// UserUpdateForm - форма изменения пользователя. Пустые поля не изменяются.
+type UserUpdateForm struct {
+	// Новый адрес электронной почты.
+	Email null.String `json:"email"`
+
+	// Новый никнейм.
+	Name null.String `json:"name"`
+
+	// Версия пользователя, известная клиенту (см. User.GetVersion). Если пользователь был изменён после неё,
+	// API отклоняет запрос со статусом 412 Precondition Failed, а клиент возвращает ErrUserModified. Остальные ошибки,
+	// в том числе 409 Conflict (например, если адрес электронной почты занят), возвращаются без изменений. Если
+	// не задана, проверка не выполняется.
+	ExpectedVersion null.Time `json:"expectedVersion"`
+}
+
 func (c *Client) getUser(id string) (*User, error) {
 	return InvokeEndpoint[User](c, http.MethodGet, fmt.Sprintf("/users/%s", id), nil)
 }
@@ -134,7 +297,60 @@ func (c *Client) GetUser(id int64) (*User, error) {
 
 // GetCurrentUser получает информацию о владельце учётных данных, с помощью которых производится авторизация.
 func (c *Client) GetCurrentUser() (*User, error) {
-	return c.getUser(CurrentUserReference)
+	return c.getUser(c.getCurrentUserReference())
+}
+
+// ListUsers получает страницу списка пользователей, подходящих под условия поиска options.
+func (c *Client) ListUsers(options UserListOptions) (*UserPage, error) {
+	return InvokeEndpoint[UserPage](c, http.MethodGet, "/users?"+options.values().Encode(), nil)
+}
+
+// ListAllUsers получает всех пользователей, подходящих под условия поиска options, последовательно запрашивая
+// страницы начиная с options.Page.
+func (c *Client) ListAllUsers(options UserListOptions) ([]User, error) {
+	if options.Page < 1 {
+		options.Page = 1
+	}
+
+	var users []User
+	for {
+		page, err := c.ListUsers(options)
+		if err != nil {
+			return nil, fmt.Errorf("getting page %d: %s", options.Page, err)
+		}
+
+		users = append(users, page.Users...)
+		if len(page.Users) == 0 || !page.HasNextPage() {
+			return users, nil
+		}
+
+		options.Page++
+	}
+}
+
+func (c *Client) updateUser(id string, form UserUpdateForm) (*User, error) {
+	user, err := InvokeEndpoint[User](c, http.MethodPatch, fmt.Sprintf("/users/%s", id), form)
+	if err != nil {
+		var errorResponse *ErrorResponse
+		if errors.As(err, &errorResponse) && errorResponse.Status == http.StatusPreconditionFailed {
+			return nil, fmt.Errorf("%w: %s", ErrUserModified, errorResponse.Message)
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// UpdateUser изменяет пользователя с указанным идентификатором. Чтобы не перезаписать чужие изменения,
+// задайте в форме версию пользователя ExpectedVersion или используйте User.Update.
+func (c *Client) UpdateUser(id int64, form UserUpdateForm) (*User, error) {
+	return c.updateUser(strconv.FormatInt(id, 10), form)
+}
+
+// UpdateCurrentUser изменяет владельца учётных данных, с помощью которых производится авторизация.
+func (c *Client) UpdateCurrentUser(form UserUpdateForm) (*User, error) {
+	return c.updateUser(c.getCurrentUserReference(), form)
 }
 
 // GetOwnedServers получает список серверов, владельцем которых является пользователь с заданным идентификатором ownerID.
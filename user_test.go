@@ -0,0 +1,71 @@
+package superhub
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestClient_ListUsers(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		query = request.URL.RawQuery
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(`{"items": [{"id": 7, "name": "Steve"}], "page": 2, "size": 1, "total": 3}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	page, err := client.ListUsers(UserListOptions{Query: "steve@", Sort: UserSortByCreatedAt, Order: SortDescending, Page: 2, PageSize: 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, query, "order=desc&page=2&query=steve%40&size=1&sort=createdAt")
+	assert.Equal(t, page.Users[0].ID, int64(7))
+	assert.Equal(t, page.HasNextPage(), true)
+}
+
+func TestUser_UpdateConflict(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		buffer, _ := io.ReadAll(request.Body)
+		body = string(buffer)
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusPreconditionFailed)
+		_, _ = writer.Write([]byte(`{"error": "Precondition Failed", "message": "user was updated", "status": 412}`))
+	}))
+	defer server.Close()
+
+	user := &User{ID: 1, Name: "Steve", CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	err := user.Update(&Client{BaseURL: server.URL}, UserUpdateForm{Name: null.StringFrom("Alex")})
+
+	assert.Equal(t, errors.Is(err, ErrUserModified), true)
+	assert.Equal(t, body, `{"email":null,"name":"Alex","expectedVersion":"2024-01-02T03:04:05Z"}`+"\n")
+	assert.Equal(t, user.Name, "Steve")
+}
+
+func TestClient_UpdateUserConflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusConflict)
+		_, _ = writer.Write([]byte(`{"error": "Conflict", "message": "email is already taken", "status": 409}`))
+	}))
+	defer server.Close()
+
+	// Конфликт, не связанный с версией пользователя, возвращается без изменений.
+	_, err := (&Client{BaseURL: server.URL}).UpdateUser(1, UserUpdateForm{Email: null.StringFrom("alex@example.com")})
+	assert.Equal(t, errors.Is(err, ErrUserModified), false)
+
+	var errorResponse *ErrorResponse
+	assert.Equal(t, errors.As(err, &errorResponse), true)
+	assert.Equal(t, errorResponse.Status, http.StatusConflict)
+}
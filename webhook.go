@@ -0,0 +1,378 @@
+package superhub
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader - заголовок, содержащий подпись тела запроса в шестнадцатеричном виде.
+	WebhookSignatureHeader = "X-Superhub-Signature"
+
+	// WebhookTimestampHeader - заголовок, содержащий время отправки запроса в формате Unix time (в секундах).
+	WebhookTimestampHeader = "X-Superhub-Timestamp"
+
+	// DefaultWebhookTolerance - максимальная разница между временем отправки запроса и текущим временем, при которой
+	// запрос считается действительным.
+	DefaultWebhookTolerance = 5 * time.Minute
+
+	// maxWebhookBodySize ограничивает размер тела запроса, который обработчик готов прочитать.
+	maxWebhookBodySize = 1 << 20
+)
+
+var (
+	// ErrInvalidWebhookSignature возвращается, если подпись запроса не совпадает с ожидаемой.
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+	// ErrStaleWebhook возвращается, если время отправки запроса отличается от текущего больше, чем допускается.
+	ErrStaleWebhook = errors.New("webhook timestamp is outside of the tolerance window")
+)
+
+// WebhookEventType - тип события, о котором платформа уведомляет через webhook.
+type WebhookEventType string
+
+const (
+	// WebhookPaymentCreated - создан новый платёж (см. PaymentEvent).
+	WebhookPaymentCreated WebhookEventType = "payment.created"
+
+	// WebhookPaymentCompleted - платёж завершён, т.е. его сумма зачислена на баланс или списана с него
+	// (см. PaymentEvent).
+	WebhookPaymentCompleted WebhookEventType = "payment.completed"
+
+	// WebhookServerStateChanged - изменилось состояние сервера (см. ServerStateChangedEvent).
+	WebhookServerStateChanged WebhookEventType = "server.state_changed"
+
+	// WebhookServerBlocked - сервер заблокирован (см. ServerBlockedEvent).
+	WebhookServerBlocked WebhookEventType = "server.blocked"
+
+	// WebhookUserBalanceChanged - изменился баланс пользователя (см. UserBalanceChangedEvent).
+	WebhookUserBalanceChanged WebhookEventType = "user.balance_changed"
+)
+
+// WebhookEvent - общая часть всех событий, получаемых через webhook.
+type WebhookEvent struct {
+	// Идентификатор события. Повторная доставка одного и того же события происходит с тем же идентификатором.
+	ID string `json:"id"`
+
+	// Тип события.
+	Type WebhookEventType `json:"type"`
+
+	// Дата возникновения события.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Данные события. Их структура зависит от типа события.
+	Data json.RawMessage `json:"data"`
+}
+
+func (e *WebhookEvent) event() *WebhookEvent {
+	return e
+}
+
+// PaymentEvent - событие, связанное с платежом.
+type PaymentEvent struct {
+	WebhookEvent `json:"-"`
+
+	// Платёж в состоянии на момент возникновения события.
+	Payment Payment `json:"payment"`
+}
+
+// ServerStateChangedEvent - событие изменения состояния сервера.
+type ServerStateChangedEvent struct {
+	WebhookEvent `json:"-"`
+
+	// Идентификатор сервера.
+	ServerID int64 `json:"serverId"`
+
+	// Состояние сервера до изменения.
+	PreviousState ServerState `json:"previousState"`
+
+	// Текущее состояние сервера.
+	State ServerState `json:"state"`
+}
+
+// ServerBlockedEvent - событие блокировки сервера.
+type ServerBlockedEvent struct {
+	WebhookEvent `json:"-"`
+
+	// Идентификатор сервера.
+	ServerID int64 `json:"serverId"`
+
+	// Идентификатор владельца сервера.
+	OwnerID int64 `json:"ownerId"`
+
+	// Причина блокировки. Например, недостаточно средств на балансе владельца.
+	Reason string `json:"reason"`
+}
+
+// UserBalanceChangedEvent - событие изменения баланса пользователя.
+type UserBalanceChangedEvent struct {
+	WebhookEvent `json:"-"`
+
+	// Идентификатор пользователя.
+	UserID int64 `json:"userId"`
+
+	// Баланс до изменения.
+	PreviousBalance PaymentAmount `json:"previousBalance"`
+
+	// Текущий баланс.
+	Balance PaymentAmount `json:"balance"`
+}
+
+// WebhookHandler - http.Handler, принимающий события платформы. Обработчик проверяет подпись и время отправки
+// каждого запроса, отбрасывает повторно доставленные события и передаёт остальные зарегистрированным функциям.
+// События без идентификатора отклоняются со статусом 400, так как их повторную доставку невозможно распознать.
+//
+// Если функция обработки события вернула ошибку, обработчик ответит статусом 500, и платформа повторит доставку.
+// Повторная доставка события, которое в этот момент ещё обрабатывается, получит статус 409, чтобы платформа
+// повторила её позже: если обработка завершится ошибкой, событие не будет потеряно.
+type WebhookHandler struct {
+	// Секрет, которым платформа подписывает запросы.
+	Secret []byte
+
+	// Максимальная разница между временем отправки запроса и текущим временем. Если не задана, используется
+	// DefaultWebhookTolerance.
+	Tolerance time.Duration
+
+	mutex     sync.Mutex
+	callbacks map[WebhookEventType][]func(event *WebhookEvent) error
+	handling  map[string]struct{}
+	handled   map[string]time.Time
+}
+
+// webhookDelivery - состояние обработки события на момент получения его очередной доставки.
+type webhookDelivery int
+
+const (
+	webhookDeliveryNew webhookDelivery = iota
+	webhookDeliveryHandling
+	webhookDeliveryHandled
+)
+
+// NewWebhookHandler создаёт обработчик событий, проверяющий подпись запросов с помощью секрета secret.
+func NewWebhookHandler(secret []byte) *WebhookHandler {
+	return &WebhookHandler{Secret: secret}
+}
+
+// On регистрирует функцию, которая будет вызвана для каждого события типа eventType. Данные события передаются
+// функции без разбора, поэтому этот метод подходит и для типов событий, не известных библиотеке.
+func (h *WebhookHandler) On(eventType WebhookEventType, callback func(event *WebhookEvent) error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.callbacks == nil {
+		h.callbacks = make(map[WebhookEventType][]func(event *WebhookEvent) error)
+	}
+
+	h.callbacks[eventType] = append(h.callbacks[eventType], callback)
+}
+
+// OnPaymentCreated регистрирует функцию, которая будет вызвана при создании платежа.
+func (h *WebhookHandler) OnPaymentCreated(callback func(event *PaymentEvent) error) {
+	onTypedWebhookEvent(h, WebhookPaymentCreated, callback)
+}
+
+// OnPaymentCompleted регистрирует функцию, которая будет вызвана при завершении платежа.
+func (h *WebhookHandler) OnPaymentCompleted(callback func(event *PaymentEvent) error) {
+	onTypedWebhookEvent(h, WebhookPaymentCompleted, callback)
+}
+
+// OnServerStateChanged регистрирует функцию, которая будет вызвана при изменении состояния сервера.
+func (h *WebhookHandler) OnServerStateChanged(callback func(event *ServerStateChangedEvent) error) {
+	onTypedWebhookEvent(h, WebhookServerStateChanged, callback)
+}
+
+// OnServerBlocked регистрирует функцию, которая будет вызвана при блокировке сервера.
+func (h *WebhookHandler) OnServerBlocked(callback func(event *ServerBlockedEvent) error) {
+	onTypedWebhookEvent(h, WebhookServerBlocked, callback)
+}
+
+// OnUserBalanceChanged регистрирует функцию, которая будет вызвана при изменении баланса пользователя.
+func (h *WebhookHandler) OnUserBalanceChanged(callback func(event *UserBalanceChangedEvent) error) {
+	onTypedWebhookEvent(h, WebhookUserBalanceChanged, callback)
+}
+
+func onTypedWebhookEvent[E any, P interface {
+	*E
+	event() *WebhookEvent
+}](h *WebhookHandler, eventType WebhookEventType, callback func(event P) error) {
+	h.On(eventType, func(event *WebhookEvent) error {
+		typed := P(new(E))
+
+		err := json.Unmarshal(event.Data, typed)
+		if err != nil {
+			return fmt.Errorf("decoding %s event data: %s", eventType, err)
+		}
+
+		*typed.event() = *event
+		return callback(typed)
+	})
+}
+
+func (h *WebhookHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(request.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(writer, "reading request body", http.StatusBadRequest)
+		return
+	}
+
+	err = VerifyWebhookSignature(h.Secret, request.Header, body, time.Now(), h.getTolerance())
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var event WebhookEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		http.Error(writer, "decoding event", http.StatusBadRequest)
+		return
+	}
+
+	if event.ID == "" {
+		http.Error(writer, "missing event id", http.StatusBadRequest)
+		return
+	}
+
+	switch h.begin(event.ID) {
+	case webhookDeliveryHandled:
+		// Событие уже было обработано, повторная доставка подтверждается без повторной обработки.
+		writer.WriteHeader(http.StatusOK)
+		return
+	case webhookDeliveryHandling:
+		http.Error(writer, "event is being handled", http.StatusConflict)
+		return
+	}
+
+	err = h.dispatch(&event)
+	h.finish(event.ID, err == nil)
+	if err != nil {
+		http.Error(writer, "handling event", http.StatusInternalServerError)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (h *WebhookHandler) dispatch(event *WebhookEvent) error {
+	h.mutex.Lock()
+	callbacks := h.callbacks[event.Type]
+	h.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		err := callback(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *WebhookHandler) getTolerance() time.Duration {
+	if h.Tolerance <= 0 {
+		return DefaultWebhookTolerance
+	}
+
+	return h.Tolerance
+}
+
+// begin возвращает состояние обработки события с идентификатором id и, если событие получено впервые, отмечает его
+// как обрабатываемое. Идентификаторы обработанных событий хранятся в течение удвоенного допустимого отклонения
+// времени: более старые события будут отброшены при проверке времени отправки.
+func (h *WebhookHandler) begin(id string) webhookDelivery {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := time.Now()
+	for handledID, handledAt := range h.handled {
+		if now.Sub(handledAt) > 2*h.getTolerance() {
+			delete(h.handled, handledID)
+		}
+	}
+
+	if _, ok := h.handled[id]; ok {
+		return webhookDeliveryHandled
+	}
+
+	if _, ok := h.handling[id]; ok {
+		return webhookDeliveryHandling
+	}
+
+	if h.handling == nil {
+		h.handling = make(map[string]struct{})
+	}
+
+	h.handling[id] = struct{}{}
+	return webhookDeliveryNew
+}
+
+// finish снимает с события с идентификатором id отметку об обработке. Если событие обработано успешно, его повторные
+// доставки будут подтверждаться без обработки, иначе следующая доставка будет обработана заново.
+func (h *WebhookHandler) finish(id string, handled bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.handling, id)
+	if !handled {
+		return
+	}
+
+	if h.handled == nil {
+		h.handled = make(map[string]time.Time)
+	}
+
+	h.handled[id] = time.Now()
+}
+
+// SignWebhookPayload вычисляет подпись тела запроса body, отправленного в момент timestamp.
+// Подпись - HMAC-SHA256 от строки "<timestamp>.<body>" в шестнадцатеричном виде.
+func SignWebhookPayload(secret []byte, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature проверяет подпись и время отправки запроса с заголовками header и телом body.
+// Вернёт ErrInvalidWebhookSignature, если подпись неверна, и ErrStaleWebhook, если время отправки отличается от now
+// больше, чем на tolerance.
+func VerifyWebhookSignature(secret []byte, header http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidWebhookSignature)
+	}
+
+	timestamp := time.Unix(seconds, 0)
+	if timestamp.Before(now.Add(-tolerance)) || timestamp.After(now.Add(tolerance)) {
+		return ErrStaleWebhook
+	}
+
+	signature, err := hex.DecodeString(strings.TrimSpace(header.Get(WebhookSignatureHeader)))
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidWebhookSignature)
+	}
+
+	expected, _ := hex.DecodeString(SignWebhookPayload(secret, timestamp, body))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
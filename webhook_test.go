@@ -0,0 +1,130 @@
+package superhub
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+var testWebhookSecret = []byte("webhook-secret")
+
+func newWebhookRequest(body []byte, timestamp time.Time, secret []byte) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/webhooks/superhub", bytes.NewReader(body))
+	request.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	request.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, timestamp, body))
+	return request
+}
+
+func TestWebhookHandler_ServeHTTP(t *testing.T) {
+	handler := NewWebhookHandler(testWebhookSecret)
+
+	var received []*PaymentEvent
+	handler.OnPaymentCompleted(func(event *PaymentEvent) error {
+		received = append(received, event)
+		return nil
+	})
+
+	body := []byte(`{"id": "evt-1", "type": "payment.completed", "createdAt": "2024-01-02T03:04:05Z",
+		"data": {"payment": {"id": "abc", "userId": 42, "amount": {"sum": 150.5, "currency": "RUB"}, "completed": true}}}`)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newWebhookRequest(body, time.Now(), testWebhookSecret))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+
+	// Повторная доставка того же события не должна приводить к повторной обработке.
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newWebhookRequest(body, time.Now(), testWebhookSecret))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+
+	assert.Equal(t, len(received), 1)
+	assert.Equal(t, received[0].ID, "evt-1")
+	assert.Equal(t, received[0].Payment.UserID, int64(42))
+	assert.Equal(t, received[0].Payment.Amount.Sum, NewMoney(15050, CurrencyRUB))
+	assert.Equal(t, received[0].Payment.Completed, true)
+}
+
+func TestWebhookHandler_Rejects(t *testing.T) {
+	handler := NewWebhookHandler(testWebhookSecret)
+	handler.On(WebhookPaymentCreated, func(*WebhookEvent) error {
+		t.Error("unexpected dispatch")
+		return nil
+	})
+
+	body := []byte(`{"id": "evt-2", "type": "payment.created", "data": {}}`)
+	requests := []*http.Request{
+		newWebhookRequest(body, time.Now(), []byte("other-secret")),
+		newWebhookRequest(body, time.Now().Add(-time.Hour), testWebhookSecret),
+		newWebhookRequest(body, time.Now().Add(time.Hour), testWebhookSecret),
+	}
+
+	for _, request := range requests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestWebhookHandler_ConcurrentDelivery(t *testing.T) {
+	handler := NewWebhookHandler(testWebhookSecret)
+
+	started := make(chan struct{})
+	release := make(chan error)
+	var calls atomic.Int32
+	handler.On(WebhookPaymentCreated, func(*WebhookEvent) error {
+		if calls.Add(1) == 1 {
+			close(started)
+			return <-release
+		}
+
+		return nil
+	})
+
+	body := []byte(`{"id": "evt-3", "type": "payment.created", "data": {}}`)
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(first, newWebhookRequest(body, time.Now(), testWebhookSecret))
+		close(done)
+	}()
+	<-started
+
+	// Пока первая доставка обрабатывается, повторная не подтверждается, чтобы платформа повторила её позже.
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newWebhookRequest(body, time.Now(), testWebhookSecret))
+	assert.Equal(t, recorder.Code, http.StatusConflict)
+
+	release <- errors.New("handler failed")
+	<-done
+	assert.Equal(t, first.Code, http.StatusInternalServerError)
+
+	// После неудачной обработки событие обрабатывается заново.
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newWebhookRequest(body, time.Now(), testWebhookSecret))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, calls.Load(), int32(2))
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newWebhookRequest(body, time.Now(), testWebhookSecret))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, calls.Load(), int32(2))
+}
+
+func TestWebhookHandler_MissingEventID(t *testing.T) {
+	handler := NewWebhookHandler(testWebhookSecret)
+	handler.On(WebhookPaymentCreated, func(*WebhookEvent) error {
+		t.Error("unexpected dispatch")
+		return nil
+	})
+
+	body := []byte(`{"type": "payment.created", "data": {}}`)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newWebhookRequest(body, time.Now(), testWebhookSecret))
+	assert.Equal(t, recorder.Code, http.StatusBadRequest)
+}